@@ -1,8 +1,10 @@
 package compare
 
 import (
+	"fmt"
 	"math"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -23,6 +25,37 @@ type BasicEqualer interface {
 	// TODO: add functions for remaining basic types
 }
 
+// TimeEqualer provides functions for determining if times and durations are
+// equal. A BasicEqualer may implement this interface to customize how a
+// DeepEqualer compares time.Time and time.Duration values.
+type TimeEqualer interface {
+	// Time determines if two times are equal.
+	Time(time.Time, time.Time) bool
+	// Duration determines if two durations are equal.
+	Duration(time.Duration, time.Duration) bool
+}
+
+// ToleranceDescriber provides a function for describing why two values that
+// aren't identical are considered equal. A BasicEqualer may implement this
+// interface to explain its decisions, e.g. in reports.
+type ToleranceDescriber interface {
+	// DescribeTolerance describes which rule makes two values that aren't
+	// identical equal. It returns an empty string if there's no such rule.
+	DescribeTolerance(a, b interface{}) string
+}
+
+// describeTolerance describes why a BasicEqualer considers two values that
+// aren't identical equal. If the BasicEqualer doesn't implement
+// ToleranceDescriber, the description is the name of its type.
+func describeTolerance(e BasicEqualer, a, b interface{}) string {
+	if td, ok := e.(ToleranceDescriber); ok {
+		if desc := td.DescribeTolerance(a, b); desc != "" {
+			return desc
+		}
+	}
+	return fmt.Sprintf("%T", e)
+}
+
 // StringTransformer provides a function for transforming strings.
 type StringTransformer interface {
 	// Transform transforms a string into another string.
@@ -50,11 +83,30 @@ type TolerantBasicEqualer struct {
 	// StringTransformer specifies how string values should be transformed
 	// before comparing them.
 	StringTransformer StringTransformer
+	// StringEqualer specifies how (transformed) string values should be
+	// compared, e.g. with a FuzzyStringEqualer.
+	StringEqualer StringEqualer
 	// TimeLayout specifies the layout of time values.
 	TimeLayout string
+	// TimeParser specifies how strings are parsed as times. If set, it takes
+	// precedence over TimeLayout, and strings are compared as times even if no
+	// TimeTolerance is specified.
+	TimeParser *TimeParser
 	// TimeTolerance specifies how much two times may differ while still being
 	// considered equal.
 	TimeTolerance time.Duration
+	// TimeIgnoreMonotonic specifies whether monotonic clock readings should be
+	// discarded before comparing times.
+	TimeIgnoreMonotonic bool
+	// TimeIgnoreLocation specifies whether times should be compared by their
+	// wall clock readings, disregarding their locations.
+	TimeIgnoreLocation bool
+	// TimePrecision specifies to which multiple of a duration times should be
+	// truncated before comparing them.
+	TimePrecision time.Duration
+	// DurationTolerance specifies how much two durations may differ while
+	// still being considered equal.
+	DurationTolerance time.Duration
 }
 
 // Bool compares two Boolean values exactly.
@@ -84,38 +136,147 @@ func (e TolerantBasicEqualer) Complex128(a, b complex128) bool {
 	return a == b
 }
 
+// Time compares two times within a tolerance. For example, if the tolerance
+// is a second, then 14:36:09.778 and 14:36:10.778 are considered equal, but
+// 14:36:09.778 and 14:36:10.779 are not.
+//
+// By default, times are compared as instants, so the same instant in different
+// locations is considered equal. If both times carry a monotonic clock
+// reading, it is used for the comparison (cf. time.Time.Sub) unless
+// TimeIgnoreMonotonic is set. If TimeIgnoreLocation is set, the wall clock
+// readings are compared instead, so 14:36 UTC+2 and 14:36 UTC are considered
+// equal. If TimePrecision is set, both times are truncated to a multiple of it
+// before comparing them.
+func (e TolerantBasicEqualer) Time(a, b time.Time) bool {
+	if e.TimeIgnoreMonotonic {
+		a, b = a.Round(0), b.Round(0)
+	}
+	if e.TimeIgnoreLocation {
+		a, b = wallClock(a), wallClock(b)
+	}
+	if e.TimePrecision > 0 {
+		a, b = a.Truncate(e.TimePrecision), b.Truncate(e.TimePrecision)
+	}
+	diff := math.Abs(float64(a.Sub(b).Nanoseconds()))
+	tol := float64(e.TimeTolerance.Nanoseconds())
+	return diff <= tol
+}
+
+// Duration compares two durations within a tolerance.
+func (e TolerantBasicEqualer) Duration(a, b time.Duration) bool {
+	if a < b {
+		a, b = b, a
+	}
+	// the difference always fits into an uint64, even if it would overflow a Duration
+	return uint64(a)-uint64(b) <= uint64(e.DurationTolerance)
+}
+
 // String compares two string values.
 //
 // If a tolerance for time values is specified, and if both values represent
 // valid times according to the specified layout (time.RFC3339 by default), then
-// this function checks if the times are within the specified tolerance.
+// this function compares them as described for Time. The same applies if a
+// TimeParser is specified and both values can be parsed with it.
 // For example, if the tolerance is a second, then "2018-03-30T14:36:09.778" and
 // "2018-03-30T14:36:10.778" are considered equal, but "2018-03-30T14:36:09.778"
 // and "2018-03-30T14:36:10.779" are not.
 //
 // Otherwise, if a StringTransformer is specified, it will be used to transform
-// both strings before comparing them.
-//
-// If neither of the above applies, the strings are compared exactly.
+// both strings before comparing them. If a StringEqualer is specified, it will
+// be used to compare the (transformed) strings; otherwise, they are compared
+// exactly.
 func (e TolerantBasicEqualer) String(a, b string) bool {
-	// if a tolerance for time values is specified, try comparing the strings as times
-	if e.TimeTolerance.Nanoseconds() > 0 {
+	same, _ := e.compareStrings(a, b)
+	return same
+}
+
+// DescribeTolerance describes which of the options of the TolerantBasicEqualer
+// makes two values that aren't identical equal.
+func (e TolerantBasicEqualer) DescribeTolerance(a, b interface{}) string {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok && a != b && e.Float64(a, b) {
+			return fmt.Sprintf("Float64Tolerance: %v", e.Float64Tolerance)
+		}
+	case string:
+		if b, ok := b.(string); ok && a != b {
+			if same, rule := e.compareStrings(a, b); same {
+				return rule
+			}
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok && !a.Equal(b) && e.Time(a, b) {
+			return e.timeRule()
+		}
+	case time.Duration:
+		if b, ok := b.(time.Duration); ok && a != b && e.Duration(a, b) {
+			return fmt.Sprintf("DurationTolerance: %v", e.DurationTolerance)
+		}
+	}
+	return ""
+}
+
+// timeRule describes which options make two times that aren't identical equal.
+func (e TolerantBasicEqualer) timeRule() string {
+	var opts []string
+	if e.TimeTolerance > 0 {
+		opts = append(opts, fmt.Sprintf("TimeTolerance: %v", e.TimeTolerance))
+	}
+	if e.TimePrecision > 0 {
+		opts = append(opts, fmt.Sprintf("TimePrecision: %v", e.TimePrecision))
+	}
+	if e.TimeIgnoreLocation {
+		opts = append(opts, "TimeIgnoreLocation")
+	}
+	if e.TimeIgnoreMonotonic {
+		opts = append(opts, "TimeIgnoreMonotonic")
+	}
+	if len(opts) == 0 {
+		// the strings represent the same instant in different formats
+		return "TimeParser"
+	}
+	return strings.Join(opts, ", ")
+}
+
+// compareStrings compares two string values, as described for String.
+// It also returns which option decided the comparison (if any).
+func (e TolerantBasicEqualer) compareStrings(a, b string) (bool, string) {
+	// if a parser for time values is specified, try comparing the strings as times
+	if e.TimeParser != nil {
+		ta, oka := e.TimeParser.Parse(a)
+		tb, okb := e.TimeParser.Parse(b)
+		if oka && okb {
+			return e.Time(ta, tb), e.timeRule()
+		}
+	} else if e.TimeTolerance.Nanoseconds() > 0 {
+		// if a tolerance for time values is specified, try comparing the strings as times
 		ta, erra := time.Parse(e.TimeLayout, a)
 		tb, errb := time.Parse(e.TimeLayout, b)
 		if erra == nil && errb == nil {
-			diff := math.Abs(float64(ta.Sub(tb).Nanoseconds()))
-			tol := float64(e.TimeTolerance.Nanoseconds())
-			return diff <= tol
+			return e.Time(ta, tb), e.timeRule()
 		}
 	}
 
 	// try transforming strings before comparing them
 	if e.StringTransformer != nil {
-		ta := e.StringTransformer.Transform(a)
-		tb := e.StringTransformer.Transform(b)
-		return ta == tb
+		a = e.StringTransformer.Transform(a)
+		b = e.StringTransformer.Transform(b)
+		if a == b {
+			return true, "StringTransformer"
+		}
+	}
+
+	if e.StringEqualer != nil {
+		return e.StringEqualer.Equal(a, b), "StringEqualer"
 	}
 
 	// if all else fails, compare the strings exactly
-	return a == b
+	return a == b, ""
+}
+
+// wallClock returns the instant at which the UTC wall clock shows the same
+// reading as the wall clock of t in its location.
+func wallClock(t time.Time) time.Time {
+	_, offset := t.Zone()
+	return t.UTC().Add(time.Duration(offset) * time.Second)
 }
@@ -1,6 +1,7 @@
 package compare
 
 import (
+	"math"
 	"regexp"
 	"testing"
 	"time"
@@ -237,3 +238,172 @@ func TestTolerantBasicEqualer_String(t *testing.T) {
 		}
 	}
 }
+
+func TestTolerantBasicEqualer_Time(t *testing.T) {
+	type testCase struct {
+		a        time.Time
+		b        time.Time
+		expected bool
+	}
+
+	utc := time.Date(2018, 3, 30, 14, 36, 9, 778000000, time.UTC)
+	cest := time.FixedZone("CEST", 2*60*60)
+	now := time.Now()
+
+	exact := []testCase{
+		{utc, utc, true},
+		{utc, utc.In(cest), true},
+		{utc, utc.Add(time.Nanosecond), false},
+		{utc.Add(time.Nanosecond), utc, false},
+		{now, now, true},
+		{now, now.Round(0), true},
+	}
+
+	approximate := []testCase{
+		{utc, utc.Add(time.Second), true},
+		{utc.Add(time.Second), utc, true},
+		{utc, utc.Add(time.Second + time.Millisecond), false},
+		{utc.Add(time.Second + time.Millisecond), utc, false},
+		{utc, utc.Add(time.Second).In(cest), true},
+	}
+
+	ignoreLocation := []testCase{
+		{utc, utc.In(cest), false},
+		{utc, time.Date(2018, 3, 30, 14, 36, 9, 778000000, cest), true},
+		{time.Date(2018, 3, 30, 14, 36, 9, 778000000, cest), utc, true},
+	}
+
+	truncated := []testCase{
+		{utc, utc.Add(20 * time.Millisecond), true},
+		{utc, utc.Add(-78 * time.Millisecond), true},
+		{utc, utc.Add(22 * time.Millisecond), false},
+		{utc, utc.Add(-79 * time.Millisecond), false},
+	}
+
+	// if no tolerance is specified, times should be compared exactly
+	e := TolerantBasicEqualer{}
+	for _, tc := range exact {
+		if actual := e.Time(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	// if a tolerance is set, times within the tolerance should be considered equal
+	e = TolerantBasicEqualer{TimeTolerance: time.Second}
+	for _, tc := range approximate {
+		if actual := e.Time(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	// if locations are ignored, wall clock readings should be compared
+	e = TolerantBasicEqualer{TimeIgnoreLocation: true}
+	for _, tc := range ignoreLocation {
+		if actual := e.Time(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	// if a precision is set, times should be truncated before comparing them
+	e = TolerantBasicEqualer{TimePrecision: 100 * time.Millisecond}
+	for _, tc := range truncated {
+		if actual := e.Time(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	// if monotonic clock readings are ignored, only wall clock readings should be compared
+	e = TolerantBasicEqualer{TimeIgnoreMonotonic: true}
+	for _, tc := range exact {
+		if actual := e.Time(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestTolerantBasicEqualer_Duration(t *testing.T) {
+	type testCase struct {
+		a        time.Duration
+		b        time.Duration
+		expected bool
+	}
+
+	exact := []testCase{
+		{0, 0, true},
+		{time.Second, time.Second, true},
+		{time.Second, time.Second + 1, false},
+		{time.Second + 1, time.Second, false},
+		{math.MinInt64, math.MaxInt64, false},
+		{math.MaxInt64, math.MinInt64, false},
+	}
+
+	approximate := []testCase{
+		{time.Second, time.Second + time.Millisecond, true},
+		{time.Second + time.Millisecond, time.Second, true},
+		{time.Second, time.Second + time.Millisecond + 1, false},
+		{-time.Millisecond, 0, true},
+		{math.MinInt64, math.MaxInt64, false},
+	}
+
+	// if no tolerance is specified, durations should be compared exactly
+	e := TolerantBasicEqualer{}
+	for _, tc := range exact {
+		if actual := e.Duration(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	// if a tolerance is set, durations within the tolerance should be considered equal
+	e = TolerantBasicEqualer{DurationTolerance: time.Millisecond}
+	for _, tc := range approximate {
+		if actual := e.Duration(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestTolerantBasicEqualer_DescribeTolerance(t *testing.T) {
+	type testCase struct {
+		a        interface{}
+		b        interface{}
+		expected string
+	}
+	utc := time.Date(2018, 3, 30, 14, 36, 9, 0, time.UTC)
+	tcs := []testCase{
+		{0.1, 0.1, ""},
+		{0.1, 0.15, "Float64Tolerance: 0.05"},
+		{0.1, 0.2, ""},
+		{0.1, "0.1", ""},
+		{"foo", "foo", ""},
+		{"foo_1", "foo_2", "StringTransformer"},
+		{"foo", "bar", ""},
+		{"2018-03-30T14:36:09Z", "2018-03-30T14:36:10Z", "TimeTolerance: 1s"},
+		{"2018-03-30T14:36:09Z", "2018-03-30T16:36:09+02:00", "TimeTolerance: 1s"},
+		{utc, utc.Add(time.Second), "TimeTolerance: 1s"},
+		{utc, utc, ""},
+		{time.Second, time.Second + time.Millisecond, "DurationTolerance: 1ms"},
+		{true, true, ""},
+	}
+	e := TolerantBasicEqualer{
+		Float64Tolerance:  0.05,
+		StringTransformer: SubstringDeleter{Regexp: regexp.MustCompile("_[^_]*$")},
+		TimeLayout:        time.RFC3339,
+		TimeTolerance:     time.Second,
+		DurationTolerance: time.Millisecond,
+	}
+	for _, tc := range tcs {
+		if actual := e.DescribeTolerance(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %q; got %q", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	// if no tolerance is specified, but times are parsed, tolerances are due to the parser
+	e = TolerantBasicEqualer{TimeParser: &TimeParser{Epoch: EpochSeconds}}
+	if actual := e.DescribeTolerance("2018-03-30T14:36:09Z", "1522420569"); actual != "TimeParser" {
+		t.Errorf("expected TimeParser; got %q", actual)
+	}
+	e = TolerantBasicEqualer{TimeIgnoreLocation: true, TimePrecision: time.Minute}
+	if actual := e.DescribeTolerance(utc, utc.Add(time.Second)); actual != "TimePrecision: 1m0s, TimeIgnoreLocation" {
+		t.Errorf("expected TimePrecision: 1m0s, TimeIgnoreLocation; got %q", actual)
+	}
+}
@@ -0,0 +1,140 @@
+package compare
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A Comparison is a named pair of JSON strings that should be compared.
+type Comparison struct {
+	Name  string
+	Left  []byte
+	Right []byte
+}
+
+// A Result is the outcome of a named comparison.
+type Result struct {
+	Name string
+	// Diff contains the differences between the compared values (if they could be compared).
+	Diff *JSONDiff
+	// Err is set if the values couldn't be compared.
+	Err error
+}
+
+// Failed returns true if the compared values differ or couldn't be compared.
+func (r Result) Failed() bool {
+	return r.Err != nil || (r.Diff != nil && r.Diff.Modified())
+}
+
+// CompareAll compares several pairs of JSON strings. Unlike Compare, it
+// doesn't return an error if some strings don't adhere to the JSON syntax;
+// instead, the error is stored in the corresponding Result.
+func (jd JSONDiffer) CompareAll(cs []Comparison) []Result {
+	results := make([]Result, len(cs))
+	for i, c := range cs {
+		results[i].Name = c.Name
+		results[i].Diff, results[i].Err = jd.Compare(c.Left, c.Right)
+	}
+	return results
+}
+
+// junitTestSuite and junitTestCase represent the parts of the JUnit XML format
+// that are commonly supported by CI systems.
+// cf. https://github.com/windyroad/JUnit-Schema/blob/master/JUnit.xsd
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes the results of several comparisons as a JUnit XML test
+// suite with the given name. Each Result is represented by a test case. If the
+// compared values differ, the test case fails, and the failure contains the
+// formatted differences. If the values couldn't be compared, the test case
+// contains an error.
+func WriteJUnit(w io.Writer, suite string, results []Result) error {
+	ts := junitTestSuite{Name: suite, Tests: len(results)}
+	for _, r := range results {
+		tc := junitTestCase{Name: r.Name, ClassName: suite}
+		switch {
+		case r.Err != nil:
+			ts.Errors++
+			tc.Error = &junitProblem{Message: r.Err.Error()}
+		case r.Failed():
+			ts.Failures++
+			diff, err := r.Diff.Format(false)
+			if err != nil {
+				return err
+			}
+			tc.Failure = &junitProblem{Message: "values differ", Text: diff}
+		}
+		ts.TestCases = append(ts.TestCases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(ts); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP writes the results of several comparisons in the Test Anything
+// Protocol (https://testanything.org/tap-version-13-specification.html).
+// Each Result is represented by a test point. If the compared values differ
+// or couldn't be compared, the test point isn't ok, and it's followed by a
+// YAML block containing the formatted differences or the error.
+func WriteTAP(w io.Writer, results []Result) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "TAP version 13\n1..%d\n", len(results))
+	for i, r := range results {
+		if !r.Failed() {
+			fmt.Fprintf(bw, "ok %d - %s\n", i+1, tapDescription(r.Name))
+			continue
+		}
+		fmt.Fprintf(bw, "not ok %d - %s\n", i+1, tapDescription(r.Name))
+		bw.WriteString("  ---\n")
+		if r.Err != nil {
+			fmt.Fprintf(bw, "  message: %s\n", strconv.Quote(r.Err.Error()))
+		} else {
+			diff, err := r.Diff.Format(false)
+			if err != nil {
+				return err
+			}
+			// the indentation indicator is needed because lines may start with spaces
+			bw.WriteString("  message: \"values differ\"\n  diff: |2\n")
+			for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+				fmt.Fprintf(bw, "    %s\n", line)
+			}
+		}
+		bw.WriteString("  ...\n")
+	}
+	return bw.Flush()
+}
+
+// tapDescription escapes a name, so that it can be used as the description of a
+// TAP test point: '#' would start a directive, and line breaks would end the line.
+var tapDescription = strings.NewReplacer("#", `\#`, "\r\n", " ", "\n", " ", "\r", " ").Replace
@@ -0,0 +1,120 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleWriteTAP() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	results := jd.CompareAll([]Comparison{
+		{Name: "same", Left: []byte(`{"a": 1}`), Right: []byte(`{"a": 1}`)},
+		{Name: "different #1", Left: []byte(`{"a": 1}`), Right: []byte(`{"a": 2}`)},
+		{Name: "invalid", Left: []byte(`{"a": 1}`), Right: []byte(`{"a": }`)},
+	})
+	if err := WriteTAP(os.Stdout, results); err != nil {
+		panic(err)
+	}
+	// Output:
+	// TAP version 13
+	// 1..3
+	// ok 1 - same
+	// not ok 2 - different \#1
+	//   ---
+	//   message: "values differ"
+	//   diff: |2
+	//      {
+	//     -  "a": 1
+	//     +  "a": 2
+	//      }
+	//   ...
+	// not ok 3 - invalid
+	//   ---
+	//   message: "invalid character '}' looking for beginning of value"
+	//   ...
+}
+
+func ExampleWriteJUnit() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	results := jd.CompareAll([]Comparison{
+		{Name: "same", Left: []byte(`{"a": 1}`), Right: []byte(`{"a": 1}`)},
+		{Name: "different", Left: []byte(`{"a": 1}`), Right: []byte(`{"a": 2}`)},
+		{Name: "invalid", Left: []byte(`{"a": 1}`), Right: []byte(`{"a": }`)},
+	})
+	if err := WriteJUnit(os.Stdout, "fixtures", results); err != nil {
+		panic(err)
+	}
+	// Output:
+	// <?xml version="1.0" encoding="UTF-8"?>
+	// <testsuite name="fixtures" tests="3" failures="1" errors="1">
+	//   <testcase name="same" classname="fixtures"></testcase>
+	//   <testcase name="different" classname="fixtures">
+	//     <failure message="values differ"><![CDATA[ {
+	// -  "a": 1
+	// +  "a": 2
+	//  }
+	// ]]></failure>
+	//   </testcase>
+	//   <testcase name="invalid" classname="fixtures">
+	//     <error message="invalid character &#39;}&#39; looking for beginning of value"></error>
+	//   </testcase>
+	// </testsuite>
+}
+
+func TestWriteJUnit(t *testing.T) {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.Compare([]byte(`"]]>"`), []byte(`"<&>"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []Result{
+		{Name: `a "quoted" <name>`, Diff: d},
+		{Name: "broken", Err: errors.New("x & y")},
+		{Name: "nothing"},
+	}
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, "suite", results); err != nil {
+		t.Fatal(err)
+	}
+
+	// the output must be well-formed, even if names and diffs contain special characters
+	var ts junitTestSuite
+	if err := xml.Unmarshal(buf.Bytes(), &ts); err != nil {
+		t.Fatalf("%v\n%s", err, buf.String())
+	}
+	if ts.Tests != 3 || ts.Failures != 1 || ts.Errors != 1 || len(ts.TestCases) != 3 {
+		t.Fatalf("unexpected test suite: %+v", ts)
+	}
+	if tc := ts.TestCases[0]; tc.Name != results[0].Name || tc.Failure == nil || !strings.Contains(tc.Failure.Text, `"]]>"`) {
+		t.Errorf("unexpected test case: %+v", tc)
+	}
+	if tc := ts.TestCases[1]; tc.Error == nil || tc.Error.Message != "x & y" {
+		t.Errorf("unexpected test case: %+v", tc)
+	}
+	if tc := ts.TestCases[2]; tc.Failure != nil || tc.Error != nil {
+		t.Errorf("unexpected test case: %+v", tc)
+	}
+}
+
+func TestTAPDescription(t *testing.T) {
+	type testCase struct {
+		name     string
+		expected string
+	}
+
+	tcs := []testCase{
+		{name: "simple", expected: "simple"},
+		{name: "# TODO", expected: `\# TODO`},
+		{name: "multi\nline\r\nname", expected: "multi line name"},
+	}
+
+	for _, tc := range tcs {
+		if actual := tapDescription(tc.name); actual != tc.expected {
+			t.Errorf("[%q] expected %q; got %q", tc.name, tc.expected, actual)
+		}
+	}
+}
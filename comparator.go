@@ -0,0 +1,94 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var boolType = reflect.TypeOf(false)
+
+// A Comparator compares two values of the same type (cf. DeepEqualer.Comparators).
+type Comparator func(a, b interface{}) ComparatorResult
+
+// ComparatorResult is the result of a Comparator.
+type ComparatorResult struct {
+	// Equal specifies whether the values are considered equal.
+	Equal bool
+	// Tolerated specifies whether the values aren't identical, even though
+	// they're considered equal. It's ignored if Equal is false.
+	Tolerated bool
+	// Message describes why the values differ, or why they were tolerated.
+	// It may be empty.
+	Message string
+}
+
+// equalCustom compares two values of the same type with a Comparator or with
+// an Equal method, if the DeepEqualer specifies that. The second return value
+// is false if neither applies, in which case the caller should compare the
+// values like it otherwise would.
+func (c *deepComparison) equalCustom(v1, v2 reflect.Value) (same, ok bool) {
+	if !v1.CanInterface() || !v2.CanInterface() {
+		// we can neither pass the values to a Comparator nor call their methods
+		return false, false
+	}
+	if cmp, ok := c.Comparators[v1.Type()]; ok {
+		res := cmp(v1.Interface(), v2.Interface())
+		rule := fmt.Sprintf("Comparators[%v]", v1.Type())
+		return c.customResult(res, v1, v2, rule), true
+	}
+	// times are compared by the BasicEqualer (cf. equalTimes), which may tolerate differences
+	if c.UseEqualMethods && v1.Type() != timeType && hasEqualMethod(v1.Type()) {
+		if isNilPointer(v1) || isNilPointer(v2) {
+			// the method may dereference its receiver or argument; equalPointers handles nil
+			return false, false
+		}
+		eq := v1.MethodByName("Equal").Call([]reflect.Value{v2})[0].Bool()
+		res := ComparatorResult{Equal: eq, Tolerated: eq && !reflect.DeepEqual(v1.Interface(), v2.Interface())}
+		return c.customResult(res, v1, v2, "Equal"), true
+	}
+	return false, false
+}
+
+// customResult records a difference or tolerance described by a ComparatorResult.
+// It returns whether the values are equal.
+func (c *deepComparison) customResult(res ComparatorResult, v1, v2 reflect.Value, rule string) bool {
+	if !res.Equal {
+		c.record(Difference{
+			Path:       c.path.clone(),
+			Operation:  OperationReplace,
+			Left:       v1.Interface(),
+			Right:      v2.Interface(),
+			Comparator: rule,
+			Message:    res.Message,
+		})
+		return false
+	}
+	if res.Tolerated && c.collect {
+		if res.Message != "" {
+			rule = res.Message
+		}
+		c.tolerances = append(c.tolerances, Tolerance{
+			Path:  c.path.clone(),
+			Left:  v1.Interface(),
+			Right: v2.Interface(),
+			Rule:  rule,
+		})
+	}
+	return true
+}
+
+// isNilPointer returns true if v is a nil pointer or interface.
+func isNilPointer(v reflect.Value) bool {
+	return (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()
+}
+
+// hasEqualMethod returns true if t has a method like `func (T) Equal(T) bool`.
+func hasEqualMethod(t reflect.Type) bool {
+	m, ok := t.MethodByName("Equal")
+	if !ok {
+		return false
+	}
+	// the receiver is the first argument
+	mt := m.Type
+	return mt.NumIn() == 2 && mt.In(1) == t && mt.NumOut() == 1 && mt.Out(0) == boolType
+}
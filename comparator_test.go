@@ -0,0 +1,181 @@
+package compare
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+func ExampleDeepEqualer_Comparators() {
+	e := DeepEqualer{
+		BasicEqualer: TolerantBasicEqualer{},
+		Comparators: map[reflect.Type]Comparator{
+			reflect.TypeOf(money{}): func(a, b interface{}) ComparatorResult {
+				x, y := a.(money), b.(money)
+				if x.Currency != y.Currency {
+					return ComparatorResult{Message: "currencies differ"}
+				}
+				diff := x.Cents - y.Cents
+				if diff < -1 || diff > 1 {
+					return ComparatorResult{Message: fmt.Sprintf("amounts differ by %d cents", diff)}
+				}
+				return ComparatorResult{Equal: true, Tolerated: diff != 0, Message: "rounding"}
+			},
+		},
+		UseEqualMethods: true,
+	}
+	d, err := e.Compare(
+		map[string]interface{}{"price": money{1999, "EUR"}, "tax": money{380, "EUR"}, "ip": net.ParseIP("10.0.0.1").To4()},
+		map[string]interface{}{"price": money{1899, "EUR"}, "tax": money{379, "EUR"}, "ip": net.ParseIP("10.0.0.1")})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.Comparator, diff.Message)
+	}
+	for _, t := range d.Tolerances() {
+		fmt.Println(t.Path, t.Rule)
+	}
+	// Output:
+	// price Comparators[compare.money] amounts differ by 100 cents
+	// ip Equal
+	// tax rounding
+}
+
+// version has an Equal method that ignores the label.
+type version struct {
+	Major, Minor int
+	Label        string
+}
+
+func (v version) Equal(o version) bool {
+	return v.Major == o.Major && v.Minor == o.Minor
+}
+
+// node has an Equal method with a pointer receiver.
+type node struct {
+	ID    int
+	Cache []int
+}
+
+func (n *node) Equal(o *node) bool {
+	return n.ID == o.ID
+}
+
+// loose has a method called Equal that doesn't qualify.
+type loose struct {
+	X int
+}
+
+func (l loose) Equal(o interface{}) bool {
+	return true
+}
+
+func TestDeepEqualer_Equal_custom(t *testing.T) {
+	type testCase struct {
+		a        interface{}
+		b        interface{}
+		methods  bool
+		expected bool
+	}
+	type wrapper struct {
+		v version
+	}
+	tcs := []testCase{
+		{version{1, 2, "a"}, version{1, 2, "b"}, true, true},
+		{version{1, 2, "a"}, version{1, 2, "b"}, false, false},
+		{version{1, 2, "a"}, version{1, 3, "a"}, true, false},
+		{[]version{{1, 2, "a"}}, []version{{1, 2, "b"}}, true, true},
+		{&node{1, []int{1}}, &node{1, nil}, true, true},
+		{&node{1, nil}, &node{2, nil}, true, false},
+		{(*node)(nil), &node{1, nil}, true, false},
+		{&node{1, nil}, (*node)(nil), true, false},
+		{(*node)(nil), (*node)(nil), true, true},
+		{[]*node{nil, {1, nil}}, []*node{nil, {1, []int{1}}}, true, true},
+		{node{1, []int{1}}, node{1, nil}, true, false}, // the method set of node doesn't contain Equal
+		{loose{1}, loose{2}, true, false},
+		{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.1").To4(), true, true},
+		{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.1").To4(), false, false},
+		{wrapper{version{1, 2, "a"}}, wrapper{version{1, 2, "b"}}, true, false}, // unexported field
+		{big.NewInt(1), big.NewInt(1), true, true},
+		{big.NewInt(1), new(big.Int).SetBytes([]byte{0, 1}), true, true},
+		{big.NewInt(1), big.NewInt(2), true, true}, // compared with the Comparator
+		{big.NewFloat(1), big.NewFloat(2), true, false},
+	}
+	for _, tc := range tcs {
+		e := DeepEqualer{
+			BasicEqualer: TolerantBasicEqualer{},
+			Comparators: map[reflect.Type]Comparator{
+				reflect.TypeOf(&big.Int{}): func(a, b interface{}) ComparatorResult {
+					return ComparatorResult{Equal: a.(*big.Int).Sign() == b.(*big.Int).Sign()}
+				},
+			},
+			UseEqualMethods: tc.methods,
+		}
+		actual, err := e.Equal(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestDeepEqualer_Equal_customTimes(t *testing.T) {
+	// time.Time has an Equal method, but the BasicEqualer's tolerance must still apply
+	now := time.Now()
+	a := map[string]time.Time{"t": now}
+	b := map[string]time.Time{"t": now.Add(500 * time.Millisecond)}
+	for _, methods := range []bool{false, true} {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{TimeTolerance: time.Second}, UseEqualMethods: methods}
+		if same, err := e.Equal(a, b); err != nil || !same {
+			t.Errorf("[%v] expected equal; got %v (%v)", methods, same, err)
+		}
+		e.BasicEqualer = TolerantBasicEqualer{}
+		if same, err := e.Equal(a, b); err != nil || same {
+			t.Errorf("[%v] expected different; got %v (%v)", methods, same, err)
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_custom(t *testing.T) {
+	e := DeepEqualer{
+		BasicEqualer: TolerantBasicEqualer{},
+		Comparators: map[reflect.Type]Comparator{
+			reflect.TypeOf(version{}): func(a, b interface{}) ComparatorResult {
+				// takes precedence over the Equal method
+				x, y := a.(version), b.(version)
+				return ComparatorResult{Equal: x.Major == y.Major, Tolerated: x != y}
+			},
+		},
+		UseEqualMethods: true,
+	}
+	a := map[string]interface{}{"v": version{1, 2, "a"}, "w": version{1, 2, "a"}, "n": &node{1, nil}, "m": &node{1, nil}}
+	b := map[string]interface{}{"v": version{1, 3, "b"}, "w": version{2, 2, "a"}, "n": &node{1, []int{1}}, "m": &node{2, nil}}
+	d, err := e.Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[/m replace &{1 []} &{2 []}; /w replace {1 2 a} {2 2 a}]"
+	if actual := describeDifferences(d.Differences()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+	expected = "[/n &{1 []} &{1 [1]} Equal; /v {1 2 a} {1 3 b} Comparators[compare.version]]"
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+	for _, diff := range d.Differences() {
+		if expected := map[string]string{"/m": "Equal", "/w": "Comparators[compare.version]"}[diff.Path.Pointer()]; diff.Comparator != expected {
+			t.Errorf("[%v] expected comparator %v; got %v", diff.Path, expected, diff.Comparator)
+		}
+	}
+}
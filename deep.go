@@ -17,12 +17,94 @@ package compare
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/yudai/gojsondiff"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
 )
 
 // DeepEqualer provides functionality for the deep comparison of values.
+// Since it has more fields than just the BasicEqualer, it should be
+// initialized with keyed fields, e.g.
+// DeepEqualer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}.
 type DeepEqualer struct {
 	// BasicEqualer specifies how values of basic types should be compared.
 	BasicEqualer
+	// Parallelism specifies whether the elements of large maps, slices, arrays
+	// and structs are compared concurrently.
+	Parallelism Parallelism
+	// Comparators specifies how values of specific types should be compared.
+	// They take precedence over any other way of comparing the values.
+	Comparators map[reflect.Type]Comparator
+	// UseEqualMethods specifies whether values whose types have a method like
+	// `func (T) Equal(T) bool` (e.g. net.IP) should be compared with that
+	// method rather than structurally. If the method considers values equal
+	// that aren't deeply equal, they're tolerated. Values of type time.Time
+	// are still compared as described for Equal, so that the BasicEqualer's
+	// tolerances apply.
+	UseEqualMethods bool
+	// StringTransformers are the StringTransformers that struct tags can
+	// refer to by name, e.g. `compare:"transform=trim"`.
+	StringTransformers map[string]StringTransformer
+	// NilEqualsEmpty specifies whether nil slices and maps are considered
+	// equal to empty ones. If they are, they're tolerated.
+	NilEqualsEmpty bool
+	// NilEqualsZero specifies whether nil pointers are considered equal to
+	// pointers to zero values. If they are, they're tolerated.
+	NilEqualsZero bool
+	// MissingEqualsZero specifies whether map entries whose values are zero
+	// values are considered equal to missing entries. If they are, they're
+	// tolerated.
+	MissingEqualsZero bool
+	// Defaults specifies the default values of struct fields, map entries
+	// and the values of pointers. The keys are path patterns like
+	// FieldFilter.Paths, e.g. "/Servers/*/Retries". A default value is
+	// considered equal to a missing map entry, a nil pointer and the zero
+	// value (and tolerated). Numeric default values are converted to the
+	// types of the values they're compared with, unless that would change
+	// them (e.g. 3.5 can't be the default of an int). If there's a default
+	// value for a map entry, it takes precedence over MissingEqualsZero.
+	Defaults map[string]interface{}
+	// Ignore specifies struct fields and map entries that aren't compared.
+	Ignore FieldFilter
+	// Subset specifies whether the left (expected) value only needs to be a
+	// subset of the right (actual) value: map entries and slice or array
+	// elements that only exist on the right side are ignored, and nil maps
+	// and slices are treated like empty ones. Struct fields are always
+	// compared. Differences are only reported for left values.
+	Subset bool
+	// SubsetArrays specifies how the elements of slices and arrays are
+	// matched if Subset is true. Elements of fields tagged unordered or key
+	// are matched as specified by their tags.
+	SubsetArrays ArrayMatching
+}
+
+// DeepDiff represents the differences between two values compared by a DeepEqualer.
+type DeepDiff struct {
+	differences []Difference
+	tolerances  []Tolerance
+}
+
+// Differences returns the individual differences between two values.
+func (d *DeepDiff) Differences() []Difference {
+	return d.differences
+}
+
+// Tolerances returns descriptions of basic values that aren't identical, but
+// were considered equal by the BasicEqualer.
+func (d *DeepDiff) Tolerances() []Tolerance {
+	return d.tolerances
+}
+
+// Modified returns true if DeepDiff has at least one Difference.
+func (d *DeepDiff) Modified() bool {
+	return len(d.differences) > 0
 }
 
 // Equal determines if two values contain the same information.
@@ -36,138 +118,527 @@ type DeepEqualer struct {
 // 2. For types that we don't support directly (i.e. channels, functions and
 // unsafe pointers), we try to fall back to reflect.DeepEqual(). Unfortunately,
 // this approach doesn't work for unexported struct fields, because we can't
-// access the underlying values. In such cases, we return an error.
-func (e DeepEqualer) Equal(a, b interface{}) (same bool, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			// TODO: only recover from reflect errors?
-			err = fmt.Errorf("%s", r)
-		}
-	}()
-	return e.equal(reflect.ValueOf(a), reflect.ValueOf(b))
+// access the underlying values. In such cases, we return an *UnexportedFieldError.
+//
+// Panics (e.g. in the BasicEqualer) aren't recovered from.
+//
+// Values of type time.Time are compared as instants rather than structurally.
+// If the BasicEqualer implements TimeEqualer, it is used to compare values of
+// type time.Time and time.Duration; otherwise, times are compared with
+// time.Time.Equal() and durations like any other integer. Times obtained by
+// accessing unexported struct fields are an exception: since we can't call
+// their methods, they're compared structurally, i.e. exactly and including
+// their locations and monotonic clock readings (TimeTolerance, TimePrecision
+// etc. don't apply).
+//
+// Comparators and Equal methods (if UseEqualMethods is true) can only be used
+// for values that weren't obtained by accessing unexported struct fields.
+//
+// Struct fields can be tagged to customize how they're compared, e.g.
+// `compare:"tol=0.01,unordered"`. The tag `compare:"-"` ignores a field.
+// Otherwise, the tag consists of comma-separated options:
+//
+//	tol=0.01        floating-point numbers may differ by 0.01
+//	reltol=1e-6     floating-point numbers may differ by 1e-6 times the larger absolute value
+//	timetol=1s      times and durations may differ by a second
+//	unordered       compare the elements of a slice or array regardless of their order
+//	key=ID          match the elements of a slice or array of structs by their ID fields
+//	transform=trim  transform strings with StringTransformers["trim"]
+//
+// Tolerances and transformations also apply to values nested within the
+// field's values. If a tag is invalid, a *TagError is returned.
+//
+// Struct fields and map entries that match the Ignore filter aren't compared
+// at all, regardless of their tags.
+//
+// If Subset is true, Equal determines if a is contained in b instead.
+func (e DeepEqualer) Equal(a, b interface{}) (bool, error) {
+	c, err := newDeepComparison(e, false)
+	if err != nil {
+		return false, err
+	}
+	return c.equal(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+// Compare returns the differences between two values. Values are compared as
+// described for Equal, but rather than stopping at the first difference, all
+// differences are collected. Struct fields are identified by their names, and
+// map keys by their string representations (as returned by fmt.Sprint()).
+func (e DeepEqualer) Compare(a, b interface{}) (*DeepDiff, error) {
+	c, err := newDeepComparison(e, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.equal(reflect.ValueOf(a), reflect.ValueOf(b)); err != nil {
+		return nil, err
+	}
+	return &DeepDiff{differences: c.differences, tolerances: c.tolerances}, nil
+}
+
+// deepComparison holds the state of a single comparison of two values.
+type deepComparison struct {
+	DeepEqualer
+	// collect specifies whether all differences should be collected, rather
+	// than stopping at the first difference.
+	collect bool
+	// path specifies where the values that are currently being compared are
+	// located within the values that are being compared as a whole.
+	path        Path
+	differences []Difference
+	tolerances  []Tolerance
+	pool        workerPool
+	filter      *fieldFilter
+	defaults    []pathDefault
+	// canceled returns true if a fork of the comparison became obsolete,
+	// because the comparison of preceding elements was stopped.
+	// It's nil if the comparison isn't a fork.
+	canceled func() bool
+}
+
+func newDeepComparison(e DeepEqualer, collect bool) (*deepComparison, error) {
+	filter, err := e.Ignore.compile()
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := compileDefaults(e.Defaults, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &deepComparison{
+		DeepEqualer: e,
+		collect:     collect,
+		pool:        newWorkerPool(e.Parallelism),
+		filter:      filter,
+		defaults:    defaults,
+	}, nil
+}
+
+// fork returns a new comparison of values nested within the values that are
+// currently being compared, which can be used concurrently with c. The fork
+// is canceled if c is, or if canceled returns true.
+func (c *deepComparison) fork(canceled func() bool) *deepComparison {
+	return &deepComparison{
+		DeepEqualer: c.DeepEqualer,
+		collect:     c.collect,
+		path:        c.path.clone(),
+		pool:        c.pool,
+		filter:      c.filter,
+		defaults:    c.defaults,
+		canceled: func() bool {
+			return canceled() || c.isCanceled()
+		},
+	}
+}
+
+// isCanceled returns true if the comparison is an obsolete fork.
+func (c *deepComparison) isCanceled() bool {
+	return c.canceled != nil && c.canceled()
 }
 
 // nolint: gocyclo
-// The complexity is currently 11 (just above the desired maximum of 10).
+// The complexity is currently 14 (just above the desired maximum of 10).
 // I disabled the gocyclo check, because I can't think of a way to reduce the
 // cyclomatic complexity in a way that really feels like an improvement.
 // In any case, I think that the code is easy to follow as it is, and the test
 // coverage for this function is 100% despite the high number of execution paths.
-func (e DeepEqualer) equal(v1, v2 reflect.Value) (bool, error) {
+func (c *deepComparison) equal(v1, v2 reflect.Value) (bool, error) {
 	if !v1.IsValid() || !v2.IsValid() { // at least one underlying value was nil
-		return v1.IsValid() == v2.IsValid(), nil
+		if v1.IsValid() != v2.IsValid() {
+			return c.differ(v1, v2, "nil"), nil
+		}
+		return true, nil
 	}
 
 	if v1.Type() != v2.Type() {
-		return false, nil
+		return c.differ(v1, v2, "type"), nil
+	}
+
+	if c.defaults != nil {
+		if same, ok, err := c.equalDefault(v1, v2); err != nil || ok {
+			return same, err
+		}
+	}
+
+	if same, ok := c.equalCustom(v1, v2); ok {
+		return same, nil
+	}
+
+	if same, ok := c.equalTimes(v1, v2); ok {
+		return c.result(same, v1, v2, v1.Type().Name()), nil
 	}
 
 	switch v1.Kind() {
 	case reflect.Array:
-		return e.equalArrays(v1, v2)
+		return c.equalArrays(v1, v2)
 	case reflect.Interface:
-		return e.equalInterfaces(v1, v2)
+		return c.equalInterfaces(v1, v2)
 	case reflect.Map:
-		return e.equalMaps(v1, v2)
+		return c.equalMaps(v1, v2)
 	case reflect.Ptr:
-		return e.equalPointers(v1, v2)
+		return c.equalPointers(v1, v2)
 	case reflect.Slice:
-		return e.equalSlices(v1, v2)
+		return c.equalSlices(v1, v2)
 	case reflect.Struct:
-		return e.equalStructs(v1, v2)
+		return c.equalStructs(v1, v2)
 	default:
-		return e.equalValues(v1, v2)
+		return c.equalValues(v1, v2)
 	}
 }
 
-func (e DeepEqualer) equalArrays(v1, v2 reflect.Value) (bool, error) {
-	for i := 0; i < v1.Len(); i++ {
-		if eq, err := e.equal(v1.Index(i), v2.Index(i)); err != nil || !eq {
-			return false, err
+// equalChild compares two values nested within the values that are currently
+// being compared. The second return value is true if the comparison should
+// stop, either because of an error or because the values differ and we don't
+// collect all differences.
+func (c *deepComparison) equalChild(pos gojsondiff.Position, v1, v2 reflect.Value) (same, stop bool, err error) {
+	c.path = append(c.path, pos)
+	same, err = c.equal(v1, v2)
+	c.path = c.path[:len(c.path)-1]
+	return same, err != nil || (!same && !c.collect), err
+}
+
+// each calls fn for n elements of the values that are currently being
+// compared, until fn returns stop. It returns whether fn considered all
+// elements equal. If the values are large enough, the elements are compared
+// concurrently by forks of the comparison, whose results are then merged as if
+// they'd been compared sequentially.
+func (c *deepComparison) each(n int, fn func(c *deepComparison, i int) (same, stop bool, err error)) (bool, error) {
+	chunks := c.pool.chunks(c.Parallelism.Threshold, n)
+	if chunks == 1 {
+		same := true
+		for i := 0; i < n && !c.isCanceled(); i++ {
+			eq, stop, err := fn(c, i)
+			if stop {
+				return false, err
+			}
+			same = same && eq
+		}
+		return same, nil
+	}
+
+	type result struct {
+		*deepComparison
+		same bool
+		err  error
+		// panicked specifies whether fn panicked, in which case the panic is
+		// raised again by the calling goroutine.
+		panicked bool
+		panic    interface{}
+	}
+	results := make([]result, chunks)
+	// stopped is the index of the first chunk whose comparison was stopped.
+	// Subsequent chunks are canceled, since a sequential comparison wouldn't
+	// have compared their elements at all.
+	stopped := int32(chunks)
+	c.pool.split(n, chunks, func(chunk, start, end int) {
+		r := result{same: true, panicked: true}
+		r.deepComparison = c.fork(func() bool {
+			return atomic.LoadInt32(&stopped) < int32(chunk)
+		})
+		defer func() {
+			if r.panicked {
+				r.same, r.panic = false, recover()
+			}
+			if r.panicked || r.err != nil || (!r.same && !c.collect) {
+				for s := atomic.LoadInt32(&stopped); int32(chunk) < s; s = atomic.LoadInt32(&stopped) {
+					if atomic.CompareAndSwapInt32(&stopped, s, int32(chunk)) {
+						break
+					}
+				}
+			}
+			results[chunk] = r
+		}()
+		for i := start; i < end && !r.isCanceled(); i++ {
+			eq, stop, err := fn(r.deepComparison, i)
+			if stop {
+				r.same, r.err, r.panicked = false, err, false
+				return
+			}
+			r.same = r.same && eq
+		}
+		r.panicked = false
+	})
+
+	same := true
+	for _, r := range results {
+		if r.panicked {
+			panic(r.panic)
+		}
+		if r.err != nil {
+			return false, r.err
 		}
+		if !r.same && !c.collect {
+			return false, nil
+		}
+		c.differences = append(c.differences, r.differences...)
+		c.tolerances = append(c.tolerances, r.tolerances...)
+		same = same && r.same
+	}
+	return same, nil
+}
+
+func (c *deepComparison) equalArrays(v1, v2 reflect.Value) (bool, error) {
+	if c.Subset {
+		return c.equalSubsetElements(v1, v2)
 	}
-	return true, nil
+	return c.each(v1.Len(), func(c *deepComparison, i int) (bool, bool, error) {
+		return c.equalChild(gojsondiff.Index(i), v1.Index(i), v2.Index(i))
+	})
 }
 
-func (e DeepEqualer) equalInterfaces(v1, v2 reflect.Value) (bool, error) {
+func (c *deepComparison) equalInterfaces(v1, v2 reflect.Value) (bool, error) {
 	if v1.IsNil() || v2.IsNil() {
-		return v1.IsNil() == v2.IsNil(), nil
+		if v1.IsNil() != v2.IsNil() {
+			return c.differ(v1, v2, "nil"), nil
+		}
+		return true, nil
 	}
-	return e.equal(v1.Elem(), v2.Elem())
+	return c.equal(v1.Elem(), v2.Elem())
 }
 
-func (e DeepEqualer) equalMaps(v1, v2 reflect.Value) (bool, error) {
-	if v1.IsNil() != v2.IsNil() {
-		return false, nil
+func (c *deepComparison) equalMaps(v1, v2 reflect.Value) (bool, error) {
+	if v1.IsNil() != v2.IsNil() && !c.Subset {
+		return c.equalNilOrEmpty(v1, v2), nil
 	}
-	if v1.Len() != v2.Len() {
+	if v1.Len() != v2.Len() && !c.collect && c.filter == nil && c.defaults == nil && !c.MissingEqualsZero &&
+		(v1.Len() > v2.Len() || !c.Subset) {
 		return false, nil
 	}
 	if v1.Pointer() == v2.Pointer() {
 		return true, nil
 	}
-	for _, k := range v1.MapKeys() {
-		val1, val2 := v1.MapIndex(k), v2.MapIndex(k)
-		if !val1.IsValid() || !val2.IsValid() {
-			return false, nil
+	elem := v1.Type().Elem()
+	keys := c.mapKeys(v1)
+	same, err := c.each(len(keys), func(c *deepComparison, i int) (bool, bool, error) {
+		val1, val2 := v1.MapIndex(keys[i]), v2.MapIndex(keys[i])
+		if c.ignored(mapKeyName(keys[i]), elem, val1, val2) {
+			return true, false, nil
+		}
+		if !val2.IsValid() {
+			same, err := c.equalMissing(mapKeyName(keys[i]), val1, val2)
+			return same, err != nil || (!same && !c.collect), err
 		}
-		if eq, err := e.equal(val1, val2); err != nil || !eq {
+		return c.equalChild(mapKeyName(keys[i]), val1, val2)
+	})
+	if err != nil || (!same && !c.collect) || c.Subset {
+		return same, err
+	}
+	for _, k := range c.mapKeys(v2) {
+		val2 := v2.MapIndex(k)
+		if v1.MapIndex(k).IsValid() || c.ignored(mapKeyName(k), elem, reflect.Value{}, val2) {
+			continue
+		}
+		eq, err := c.equalMissing(mapKeyName(k), reflect.Value{}, val2)
+		if err != nil || (!eq && !c.collect) {
 			return false, err
 		}
+		same = same && eq
 	}
-	return true, nil
+	return same, nil
 }
 
-func (e DeepEqualer) equalPointers(v1, v2 reflect.Value) (bool, error) {
+// mapKeys returns the keys of a map. If we collect differences, the keys are
+// sorted by their string representations to make the results deterministic.
+func (c *deepComparison) mapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	if c.collect {
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+	}
+	return keys
+}
+
+func (c *deepComparison) equalPointers(v1, v2 reflect.Value) (bool, error) {
 	if v1.Pointer() == v2.Pointer() {
 		return true, nil
 	}
-	return e.equal(v1.Elem(), v2.Elem())
+	if v1.IsNil() != v2.IsNil() {
+		if same, ok := c.equalNilOrZero(v1, v2); ok {
+			return same, nil
+		}
+	}
+	return c.equal(v1.Elem(), v2.Elem())
 }
 
-func (e DeepEqualer) equalSlices(v1, v2 reflect.Value) (bool, error) {
+func (c *deepComparison) equalSlices(v1, v2 reflect.Value) (bool, error) {
+	if c.Subset {
+		return c.equalSubsetElements(v1, v2)
+	}
 	if v1.IsNil() != v2.IsNil() {
-		return false, nil
+		return c.equalNilOrEmpty(v1, v2), nil
 	}
-	if v1.Len() != v2.Len() {
+	if v1.Len() != v2.Len() && !c.collect {
 		return false, nil
 	}
-	if v1.Pointer() == v2.Pointer() {
+	if v1.Pointer() == v2.Pointer() && v1.Len() == v2.Len() {
 		return true, nil
 	}
-	for i := 0; i < v1.Len(); i++ {
-		if eq, err := e.equal(v1.Index(i), v2.Index(i)); err != nil || !eq {
-			return false, err
-		}
+	common := v1.Len()
+	if v2.Len() < common {
+		common = v2.Len()
+	}
+	same, err := c.each(common, func(c *deepComparison, i int) (bool, bool, error) {
+		return c.equalChild(gojsondiff.Index(i), v1.Index(i), v2.Index(i))
+	})
+	if err != nil || (!same && !c.collect) {
+		return false, err
+	}
+	for i := v2.Len(); i < v1.Len(); i++ {
+		same = false
+		c.record(Difference{Path: c.path.child(gojsondiff.Index(i)), Operation: OperationRemove, Left: valueOf(v1.Index(i))})
 	}
-	return true, nil
+	for i := v1.Len(); i < v2.Len(); i++ {
+		same = false
+		c.record(Difference{Path: c.path.child(gojsondiff.Index(i)), Operation: OperationAdd, Right: valueOf(v2.Index(i))})
+	}
+	return same, nil
 }
 
-func (e DeepEqualer) equalStructs(v1, v2 reflect.Value) (bool, error) {
-	for i := 0; i < v1.NumField(); i++ {
-		if eq, err := e.equal(v1.Field(i), v2.Field(i)); err != nil || !eq {
-			return false, err
+func (c *deepComparison) equalStructs(v1, v2 reflect.Value) (bool, error) {
+	opts, err := structOptions(v1.Type())
+	if err != nil {
+		return false, err
+	}
+	return c.each(v1.NumField(), func(c *deepComparison, i int) (bool, bool, error) {
+		f := v1.Type().Field(i)
+		if c.ignored(gojsondiff.Name(f.Name), f.Type, v1.Field(i), v2.Field(i)) {
+			return true, false, nil
+		}
+		return c.equalField(v1.Type(), i, opts[i], v1.Field(i), v2.Field(i))
+	})
+}
+
+// equalTimes compares values of type time.Time and time.Duration.
+// The second return value is false if the values are of some other type, or if
+// they can't be compared as times because they were obtained by accessing
+// unexported struct fields. In that case, the caller should compare the values
+// structurally, which means that the BasicEqualer's tolerances don't apply
+// (cf. Equal).
+func (c *deepComparison) equalTimes(v1, v2 reflect.Value) (same, ok bool) {
+	te, isTimeEqualer := c.BasicEqualer.(TimeEqualer)
+	switch v1.Type() {
+	case timeType:
+		if !v1.CanInterface() || !v2.CanInterface() {
+			return false, false
+		}
+		t1, t2 := v1.Interface().(time.Time), v2.Interface().(time.Time)
+		if isTimeEqualer {
+			return te.Time(t1, t2), true
+		}
+		return t1.Equal(t2), true
+	case durationType:
+		if isTimeEqualer {
+			return te.Duration(time.Duration(v1.Int()), time.Duration(v2.Int())), true
 		}
 	}
-	return true, nil
+	return false, false
 }
 
-func (e DeepEqualer) equalValues(v1, v2 reflect.Value) (bool, error) {
+func (c *deepComparison) equalValues(v1, v2 reflect.Value) (bool, error) {
 	switch v1.Kind() {
 	case reflect.Bool:
-		return e.Bool(v1.Bool(), v2.Bool()), nil
+		return c.result(c.Bool(v1.Bool(), v2.Bool()), v1, v2, "Bool"), nil
+	case reflect.Complex64, reflect.Complex128:
+		return c.result(c.Complex128(v1.Complex(), v2.Complex()), v1, v2, "Complex128"), nil
+	case reflect.Float32, reflect.Float64:
+		return c.result(c.Float64(v1.Float(), v2.Float()), v1, v2, "Float64"), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return c.result(c.Int64(v1.Int(), v2.Int()), v1, v2, "Int64"), nil
+	case reflect.String:
+		return c.result(c.String(v1.String(), v2.String()), v1, v2, "String"), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return c.result(c.Uint64(v1.Uint(), v2.Uint()), v1, v2, "Uint64"), nil
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if !v1.CanInterface() || !v2.CanInterface() {
+			return false, &UnexportedFieldError{Path: c.path.clone(), Type: v1.Type()}
+		}
+		same := reflect.DeepEqual(v1.Interface(), v2.Interface())
+		return c.result(same, v1, v2, "reflect.DeepEqual"), nil
+	default:
+		return false, &UnsupportedKindError{Path: c.path.clone(), Type: v1.Type()}
+	}
+}
+
+// result records a difference if two values aren't equal according to the
+// given comparator, and a tolerance if they are equal, but not identical.
+// It returns whether the values are equal.
+func (c *deepComparison) result(same bool, v1, v2 reflect.Value, comparator string) bool {
+	if !same {
+		return c.differ(v1, v2, comparator)
+	}
+	if c.collect {
+		if a, b := basicValueOf(v1), basicValueOf(v2); a != b {
+			c.tolerances = append(c.tolerances, Tolerance{
+				Path:  c.path.clone(),
+				Left:  valueOf(v1),
+				Right: valueOf(v2),
+				Rule:  describeTolerance(c.BasicEqualer, a, b),
+			})
+		}
+	}
+	return true
+}
+
+// differ records that two values differ according to the given comparator.
+// It always returns false, so it can be used as the result of a comparison.
+func (c *deepComparison) differ(v1, v2 reflect.Value, comparator string) bool {
+	c.record(Difference{
+		Path:       c.path.clone(),
+		Operation:  OperationReplace,
+		Left:       valueOf(v1),
+		Right:      valueOf(v2),
+		Comparator: comparator,
+	})
+	return false
+}
+
+// record records a difference if we collect differences.
+func (c *deepComparison) record(d Difference) {
+	if c.collect {
+		c.differences = append(c.differences, d)
+	}
+}
+
+// mapKeyName returns the position of a map element.
+func mapKeyName(k reflect.Value) gojsondiff.Position {
+	return gojsondiff.Name(fmt.Sprint(k))
+}
+
+// valueOf returns the value held by v. If v was obtained by accessing an
+// unexported struct field, its string representation is returned instead.
+func valueOf(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if !v.CanInterface() {
+		return fmt.Sprint(v)
+	}
+	return v.Interface()
+}
+
+// basicValueOf returns the value held by v, converted to the type that is
+// used by the corresponding function of the BasicEqualer (e.g. int64 for all
+// signed integers), or to time.Time or time.Duration.
+func basicValueOf(v reflect.Value) interface{} {
+	switch v.Type() {
+	case timeType:
+		return v.Interface()
+	case durationType:
+		return time.Duration(v.Int())
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
 	case reflect.Complex64, reflect.Complex128:
-		return e.Complex128(v1.Complex(), v2.Complex()), nil
+		return v.Complex()
 	case reflect.Float32, reflect.Float64:
-		return e.Float64(v1.Float(), v2.Float()), nil
+		return v.Float()
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return e.Int64(v1.Int(), v2.Int()), nil
+		return v.Int()
 	case reflect.String:
-		return e.String(v1.String(), v2.String()), nil
+		return v.String()
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return e.Uint64(v1.Uint(), v2.Uint()), nil
-	default: // Chan, Func, UnsafePointer
-		// panics if a value was obtained by accessing unexported struct fields
-		return reflect.DeepEqual(v1.Interface(), v2.Interface()), nil
+		return v.Uint()
 	}
+	return nil
 }
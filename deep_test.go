@@ -9,7 +9,7 @@ import (
 )
 
 func ExampleDeepEqualer_Equal_float() {
-	de := DeepEqualer{TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	de := DeepEqualer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
 	same, err := de.Equal([]float64{1.6, 3.8}, []float64{1.544, 3.89})
 	if err != nil {
 		fmt.Println(err)
@@ -20,7 +20,7 @@ func ExampleDeepEqualer_Equal_float() {
 }
 
 func ExampleDeepEqualer_Equal_string() {
-	de := DeepEqualer{TolerantBasicEqualer{
+	de := DeepEqualer{BasicEqualer: TolerantBasicEqualer{
 		// ignore everything after first space
 		StringTransformer: SubstringDeleter{Regexp: regexp.MustCompile(" .*$")},
 	}}
@@ -136,11 +136,11 @@ func TestDeepEqualer_Equal_exact(t *testing.T) {
 			Unexported{x: ch1},
 			Unexported{x: ch1},
 			false,
-			"reflect.Value.Interface: cannot return value obtained from unexported field or method",
+			`cannot compare chan int at "/x": obtained from unexported field`,
 		},
 	}
 	// since we specify no tolerances, the equaler will compare values exactly
-	e := DeepEqualer{TolerantBasicEqualer{}}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
 	for _, tc := range tcs {
 		if tc.b == (self{}) {
 			tc.b = tc.a
@@ -187,7 +187,7 @@ func TestDeepEqualer_Equal_tolerant(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	e := DeepEqualer{TolerantBasicEqualer{
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{
 		Float64Tolerance: 0.05,
 		// ignore everything after last underscore
 		StringTransformer: SubstringDeleter{Regexp: regexp.MustCompile("_[^_]*$")},
@@ -203,3 +203,187 @@ func TestDeepEqualer_Equal_tolerant(t *testing.T) {
 		}
 	}
 }
+
+func TestDeepEqualer_Equal_time(t *testing.T) {
+	type testCase struct {
+		a        interface{}
+		b        interface{}
+		expected bool
+	}
+
+	type Event struct {
+		Name    string
+		Start   time.Time
+		End     *time.Time
+		Timeout time.Duration
+	}
+
+	utc := time.Date(2018, 3, 30, 14, 36, 9, 778000000, time.UTC)
+	later := utc.Add(500 * time.Millisecond)
+	muchLater := utc.Add(2 * time.Second)
+
+	tolerant := []testCase{
+		{utc, later, true},
+		{utc, muchLater, false},
+		{&utc, &later, true},
+		{[]time.Time{utc, muchLater}, []time.Time{later, muchLater}, true},
+		{time.Second, time.Second + time.Millisecond, true},
+		{time.Second, 2 * time.Second, false},
+		{Event{"a", utc, &utc, time.Second}, Event{"a", later, &later, time.Second + 1}, true},
+		{Event{"a", utc, &utc, time.Second}, Event{"a", later, &muchLater, time.Second}, false},
+		{Event{"a", utc, nil, time.Second}, Event{"a", utc, &utc, time.Second}, false},
+		{map[string]time.Time{"a": utc}, map[string]time.Time{"a": later}, true},
+	}
+
+	// the BasicEqualer should be used to compare times and durations
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{
+		TimeTolerance:     time.Second,
+		DurationTolerance: time.Millisecond,
+	}}
+	for _, tc := range tolerant {
+		actual, err := e.Equal(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestDeepEqualer_Equal_timeOptions(t *testing.T) {
+	type testCase struct {
+		e        BasicEqualer
+		a        interface{}
+		b        interface{}
+		expected bool
+	}
+	type unexported struct {
+		t time.Time
+	}
+
+	now := time.Now() // has a monotonic clock reading
+	utc := time.Date(2018, 3, 30, 14, 36, 9, 778000000, time.UTC)
+	cest := time.FixedZone("CEST", 2*60*60)
+	sameWallClock := time.Date(2018, 3, 30, 14, 36, 9, 778000000, cest)
+	exact := TolerantBasicEqualer{}
+
+	tcs := []testCase{
+		// times are compared as instants, with or without monotonic clock readings
+		{exact, now, now.Round(0), true},
+		{exact, now, now.Add(time.Nanosecond), false},
+		{TolerantBasicEqualer{TimeIgnoreMonotonic: true}, now, now.Round(0), true},
+		{basicOnly{exact}, now, now.Round(0), true},
+		{basicOnly{exact}, now, now.Add(time.Nanosecond), false},
+		// locations don't matter, unless the wall clocks are compared
+		{exact, utc, utc.In(cest), true},
+		{exact, utc, sameWallClock, false},
+		{TolerantBasicEqualer{TimeIgnoreLocation: true}, utc, utc.In(cest), false},
+		{TolerantBasicEqualer{TimeIgnoreLocation: true}, utc, sameWallClock, true},
+		{basicOnly{exact}, utc, utc.In(cest), true},
+		// times are truncated to the precision
+		{TolerantBasicEqualer{TimePrecision: time.Second}, utc, utc.Add(200 * time.Millisecond), true},
+		{TolerantBasicEqualer{TimePrecision: time.Second}, utc, utc.Add(300 * time.Millisecond), false},
+		{TolerantBasicEqualer{TimePrecision: time.Millisecond}, utc, utc.Add(time.Millisecond), false},
+		{TolerantBasicEqualer{TimePrecision: time.Millisecond}, utc, utc.Add(999 * time.Nanosecond), true},
+		// durations may differ by the tolerance
+		{exact, time.Second, time.Second + 1, false},
+		{TolerantBasicEqualer{DurationTolerance: time.Millisecond}, time.Second, time.Second + time.Millisecond, true},
+		{TolerantBasicEqualer{DurationTolerance: time.Millisecond}, time.Second + time.Millisecond + 1, time.Second, false},
+		{TolerantBasicEqualer{TimeTolerance: time.Hour}, time.Second, 2 * time.Second, false},
+		{basicOnly{TolerantBasicEqualer{DurationTolerance: time.Millisecond}}, time.Second, time.Second + 1, false},
+		// times in unexported fields can only be compared structurally
+		{exact, unexported{utc}, unexported{utc}, true},
+		{TolerantBasicEqualer{TimeTolerance: time.Second}, unexported{utc}, unexported{utc.Add(time.Millisecond)}, false},
+		{exact, unexported{utc}, unexported{utc.In(cest)}, false},
+	}
+	for i, tc := range tcs {
+		e := DeepEqualer{BasicEqualer: tc.e}
+		if actual, err := e.Equal(tc.a, tc.b); err != nil || actual != tc.expected {
+			t.Errorf("[%d: %v == %v] expected %v; got %v (%v)", i, tc.a, tc.b, tc.expected, actual, err)
+		}
+	}
+}
+
+// basicOnly only exposes the methods of BasicEqualer, so it doesn't implement
+// TimeEqualer even if the wrapped BasicEqualer does.
+type basicOnly struct {
+	BasicEqualer
+}
+
+func TestDeepEqualer_Compare(t *testing.T) {
+	type Inner struct {
+		N int
+		s string
+	}
+	type Outer struct {
+		A []int
+		M map[string]float64
+		P *Inner
+		I interface{}
+	}
+	a := Outer{
+		A: []int{1, 2, 3},
+		M: map[string]float64{"x": 1.5, "y": 2, "z": 3},
+		P: &Inner{N: 1, s: "foo"},
+		I: 1,
+	}
+	b := Outer{
+		A: []int{1, 5},
+		M: map[string]float64{"w": 0, "x": 1.55, "y": 2.5},
+		P: &Inner{N: 1, s: "bar"},
+		I: "1",
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	d, err := e.Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Modified() {
+		t.Error("expected differences")
+	}
+	expected := []string{
+		"A[1] replace 2 5 Int64",
+		"A[2] remove 3 <nil> ",
+		"M.y replace 2 2.5 Float64",
+		"M.z remove 3 <nil> ",
+		"M.w add <nil> 0 ",
+		"P.s replace foo bar String",
+		"I replace 1 1 type",
+	}
+	actual := d.Differences()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v differences; got %v", len(expected), actual)
+	}
+	for i, diff := range actual {
+		s := fmt.Sprintf("%s %s %v %v %s", diff.Path, diff.Operation, diff.Left, diff.Right, diff.Comparator)
+		if s != expected[i] {
+			t.Errorf("expected %q; got %q", expected[i], s)
+		}
+	}
+	if tols := d.Tolerances(); len(tols) != 1 || tols[0].Path.String() != "M.x" || tols[0].Rule != "Float64Tolerance: 0.1" {
+		t.Errorf("expected tolerance for M.x; got %v", tols)
+	}
+
+	// the results of Compare and Equal are consistent
+	for _, tc := range []struct{ a, b interface{} }{{a, b}, {a, a}, {nil, a}, {[]int(nil), []int{}}} {
+		d, err := e.Compare(tc.a, tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		same, err := e.Equal(tc.a, tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same == d.Modified() {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, same, d.Differences())
+		}
+	}
+
+	// errors are reported like by Equal
+	type Unexported struct {
+		x chan int
+	}
+	if _, err := e.Compare(Unexported{make(chan int)}, Unexported{make(chan int)}); err == nil {
+		t.Error("expected error for unexported channel")
+	}
+}
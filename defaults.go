@@ -0,0 +1,202 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// pathDefault is the default value of the values at paths matching a pattern
+// (cf. DeepEqualer.Defaults and JSONDiffer.Defaults).
+type pathDefault struct {
+	pattern string
+	tokens  []string
+	value   interface{}
+}
+
+// compileDefaults prepares default values for matching. The patterns are
+// sorted, so that the same pattern is used whenever several of them match.
+// If convert isn't nil, it's applied to the default values.
+func compileDefaults(defaults map[string]interface{}, convert func(interface{}) (interface{}, error)) ([]pathDefault, error) {
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+	pds := make([]pathDefault, 0, len(defaults))
+	for pattern, value := range defaults {
+		tokens, err := compilePathPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if convert != nil {
+			if value, err = convert(value); err != nil {
+				return nil, fmt.Errorf("invalid default value for %q: %v", pattern, err)
+			}
+		}
+		pds = append(pds, pathDefault{pattern: pattern, tokens: tokens, value: value})
+	}
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].pattern < pds[j].pattern
+	})
+	return pds, nil
+}
+
+// lookupDefault returns the default value of the value identified by p.
+func lookupDefault(pds []pathDefault, p Path) (pathDefault, bool) {
+	for _, pd := range pds {
+		if matchTokens(pd.tokens, p) {
+			return pd, true
+		}
+	}
+	return pathDefault{}, false
+}
+
+// equalDefault compares two values of which one is absent (i.e. the zero
+// value), if there's a default value for the current path. The second return
+// value is false if the other value isn't the default value, in which case the
+// caller should compare the values like it otherwise would.
+func (c *deepComparison) equalDefault(v1, v2 reflect.Value) (same, ok bool, err error) {
+	pd, found := lookupDefault(c.defaults, c.path)
+	if !found {
+		return false, false, nil
+	}
+	v := v2
+	switch a1, a2 := isAbsent(v1), isAbsent(v2); {
+	case a1 == a2:
+		return false, false, nil
+	case a2:
+		v = v1
+	}
+	if same, err = c.isDefault(pd, v); err != nil || !same {
+		return false, false, err
+	}
+	c.tolerate(v1, v2, fmt.Sprintf("DeepEqualer.Defaults[%q]", pd.pattern))
+	return true, true, nil
+}
+
+// equalMissing compares a map entry that only exists on one side (i.e. v1 or
+// v2 is the zero Value) to the missing one. If the entry is neither the
+// default value nor a tolerated zero value, it's recorded as removed or added.
+func (c *deepComparison) equalMissing(pos gojsondiff.Position, v1, v2 reflect.Value) (bool, error) {
+	c.path = append(c.path, pos)
+	defer func() { c.path = c.path[:len(c.path)-1] }()
+
+	v := v1
+	if !v.IsValid() {
+		v = v2
+	}
+	rule := ""
+	if pd, ok := lookupDefault(c.defaults, c.path); ok {
+		same, err := c.isDefault(pd, v)
+		if err != nil {
+			return false, err
+		}
+		if same {
+			rule = fmt.Sprintf("DeepEqualer.Defaults[%q]", pd.pattern)
+		}
+	} else if c.MissingEqualsZero && isAbsent(v) {
+		rule = "DeepEqualer.MissingEqualsZero"
+	}
+	if rule != "" {
+		c.tolerate(v1, v2, rule)
+		return true, nil
+	}
+
+	if v1.IsValid() {
+		c.record(Difference{Path: c.path.clone(), Operation: OperationRemove, Left: valueOf(v1)})
+	} else {
+		c.record(Difference{Path: c.path.clone(), Operation: OperationAdd, Right: valueOf(v2)})
+	}
+	return false, nil
+}
+
+// isDefault determines if a value is equal to a default value. Pointers and
+// interfaces are dereferenced, and numeric default values are converted to
+// the type of the value if that's possible without changing them (cf.
+// convertExact).
+func (c *deepComparison) isDefault(pd pathDefault, v reflect.Value) (bool, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false, nil
+		}
+		v = v.Elem()
+	}
+	d := reflect.ValueOf(pd.value)
+	switch {
+	case !d.IsValid():
+		return false, nil
+	case d.Type().AssignableTo(v.Type()):
+	case isNumeric(d.Kind()) && isNumeric(v.Kind()):
+		var ok bool
+		if d, ok = convertExact(d, v.Type()); !ok {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	m := &deepComparison{DeepEqualer: c.DeepEqualer, path: c.path.clone(), filter: c.filter}
+	return m.equal(d, v)
+}
+
+// isAbsent returns true if v holds the zero value of its type.
+func isAbsent(v reflect.Value) bool {
+	for v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return !v.IsValid() || v.IsZero()
+}
+
+// convertExact converts a numeric value to another numeric type. It returns
+// false if the value would be truncated, wrapped around or overflow, e.g.
+// 3.5 to an int or -1 to a uint. Floating-point values may be rounded to
+// the nearest value of a smaller floating-point type, though, since e.g. 0.1
+// isn't exactly representable by either.
+func convertExact(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	c := v.Convert(t)
+	if isFloat(v.Kind()) && isFloat(t.Kind()) {
+		return c, !math.IsInf(c.Float(), 0) || math.IsInf(v.Float(), 0)
+	}
+	if isNegative(c) != isNegative(v) || c.Convert(v.Type()).Interface() != v.Interface() {
+		return reflect.Value{}, false
+	}
+	return c, true
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isNegative(v reflect.Value) bool {
+	switch {
+	case isFloat(v.Kind()):
+		return v.Float() < 0
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		return v.Int() < 0
+	}
+	return false
+}
+
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// normalizeJSON converts a Go value to the corresponding decoded JSON value
+// (e.g. 3 to float64(3)) by encoding it with json.Marshal and decoding the result.
+func normalizeJSON(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var n interface{}
+	err = json.Unmarshal(b, &n)
+	return n, err
+}
@@ -0,0 +1,239 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func ExampleJSONDiffer_Defaults() {
+	jd := JSONDiffer{
+		BasicEqualer:      TolerantBasicEqualer{},
+		MissingEqualsZero: true,
+		Defaults:          map[string]interface{}{"/servers/*/retries": 3},
+	}
+	d, err := jd.Compare(
+		[]byte(`{"servers": [{"host": "a", "retries": 3, "debug": false}, {"host": "b"}]}`),
+		[]byte(`{"servers": [{"host": "a"}, {"host": "b", "retries": 0}]}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.Operation, diff.Left, diff.Right)
+	}
+	for _, t := range d.Tolerances() {
+		fmt.Println(t.Path, t.Rule)
+	}
+	// Output:
+	// servers[1].retries add <nil> 0
+	// servers[0].debug JSONDiffer.MissingEqualsZero
+	// servers[0].retries JSONDiffer.Defaults["/servers/*/retries"]
+}
+
+func TestDeepEqualer_Equal_defaults(t *testing.T) {
+	type config struct {
+		Host    string
+		Retries int
+		Timeout *float64
+		Labels  map[string]interface{}
+	}
+	timeout := func(f float64) *float64 { return &f }
+	type testCase struct {
+		a        config
+		b        config
+		zero     bool
+		expected bool
+	}
+	tcs := []testCase{
+		{config{Retries: 0}, config{Retries: 3}, false, true},
+		{config{Retries: 3}, config{}, false, true},
+		{config{Retries: 2}, config{Retries: 3}, false, false},
+		{config{Retries: 0}, config{Retries: 2}, false, false},
+		{config{Timeout: nil}, config{Timeout: timeout(1.5)}, false, true},
+		{config{Timeout: timeout(0)}, config{Timeout: timeout(1.5)}, false, true},
+		{config{Timeout: nil}, config{Timeout: timeout(0)}, false, false},
+		{config{Timeout: nil}, config{Timeout: timeout(0)}, true, false}, // MissingEqualsZero only applies to map entries
+		{config{Labels: map[string]interface{}{}}, config{Labels: map[string]interface{}{"env": "prod"}}, false, true},
+		{config{Labels: map[string]interface{}{"env": ""}}, config{Labels: map[string]interface{}{"env": "prod"}}, false, true},
+		{config{Labels: map[string]interface{}{}}, config{Labels: map[string]interface{}{"env": "dev"}}, true, false},
+		{config{Labels: map[string]interface{}{"x": 0}}, config{Labels: map[string]interface{}{}}, false, false},
+		{config{Labels: map[string]interface{}{"x": 0}}, config{Labels: map[string]interface{}{}}, true, true},
+		{config{Labels: map[string]interface{}{"x": nil}}, config{Labels: map[string]interface{}{"y": false}}, true, true},
+		{config{Labels: map[string]interface{}{"x": 1}}, config{Labels: map[string]interface{}{}}, true, false},
+		{config{Labels: map[string]interface{}{"port": 80.0}}, config{Labels: map[string]interface{}{}}, false, true},
+		{config{Labels: map[string]interface{}{"port": "80"}}, config{Labels: map[string]interface{}{}}, false, false},
+		{config{Labels: map[string]interface{}{"a": "ab"}}, config{Labels: map[string]interface{}{"b": "ab"}}, false, true},
+		{config{Host: ""}, config{Host: "localhost"}, false, false},
+	}
+	for i, tc := range tcs {
+		for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+			e := DeepEqualer{
+				BasicEqualer:      TolerantBasicEqualer{},
+				Parallelism:       p,
+				MissingEqualsZero: tc.zero,
+				Defaults: map[string]interface{}{
+					"/Retries":     3,
+					"/Timeout":     1.5,
+					"/Labels/env":  "prod",
+					"/Labels/port": 80,
+					"/Labels/[ab]": "ab",
+				},
+			}
+			actual, err := e.Equal(tc.a, tc.b)
+			if err != nil {
+				t.Errorf("[%d] %v", i, err)
+			} else if actual != tc.expected {
+				t.Errorf("[%d] expected %v; got %v", i, tc.expected, actual)
+			}
+			if d, err := e.Compare(tc.a, tc.b); err != nil || d.Modified() == tc.expected {
+				t.Errorf("[%d] expected modified: %v; got %v (%v)", i, !tc.expected, d, err)
+			}
+		}
+	}
+}
+
+func TestDeepEqualer_Equal_defaultsConversion(t *testing.T) {
+	// numeric default values must only match values they can be converted to exactly
+	type testCase struct {
+		def      interface{}
+		v        interface{}
+		expected bool
+	}
+	tcs := []testCase{
+		{3, int8(3), true},
+		{3, uint(3), true},
+		{3, 3.0, true},
+		{3.0, 3, true},
+		{3.5, 3, false},
+		{3.5, uint8(3), false},
+		{-1, uint(math.MaxUint64), false},
+		{-1, uint8(255), false},
+		{-1, -1.0, true},
+		{300, uint8(44), false},
+		{uint64(math.MaxUint64), -1, false},
+		{1e30, int64(math.MinInt64), false},
+		{1<<53 + 1, float64(1 << 53), false},
+		{0.1, float32(0.1), true},
+		{1e300, float32(math.Inf(1)), false},
+	}
+	for _, tc := range tcs {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Defaults: map[string]interface{}{"/x": tc.def}}
+		a, b := map[string]interface{}{"x": tc.v}, map[string]interface{}{}
+		if actual, err := e.Equal(a, b); err != nil || actual != tc.expected {
+			t.Errorf("[%T(%v) == %T(%v)] expected %v; got %v (%v)", tc.def, tc.def, tc.v, tc.v, tc.expected, actual, err)
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_defaults(t *testing.T) {
+	type server struct {
+		Host    string
+		Retries uint8
+	}
+	e := DeepEqualer{
+		BasicEqualer:      TolerantBasicEqualer{},
+		MissingEqualsZero: true,
+		Defaults:          map[string]interface{}{"/*/Retries": 3},
+	}
+	d, err := e.Compare(
+		map[string]server{"a": {"a", 0}, "b": {"b", 3}, "c": {"c", 0}, "d": {}},
+		map[string]server{"a": {"a", 3}, "b": {"b", 0}, "c": {"c", 4}, "e": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[/c/Retries replace 0 4]"
+	if actual := describeDifferences(d.Differences()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+	expected = `[/a/Retries 0 3 DeepEqualer.Defaults["/*/Retries"]; /b/Retries 3 0 DeepEqualer.Defaults["/*/Retries"]; ` +
+		"/d { 0} <nil> DeepEqualer.MissingEqualsZero; /e <nil> { 0} DeepEqualer.MissingEqualsZero]"
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestJSONDiffer_Equal_defaults(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		zero     bool
+		expected bool
+	}
+	tcs := []testCase{
+		{`{"retries": 3}`, `{}`, false, true},
+		{`{}`, `{"retries": 3}`, false, true},
+		{`{"retries": 0}`, `{}`, false, false},
+		{`{"retries": 0}`, `{}`, true, false}, // the default takes precedence
+		{`{"retries": 3}`, `{"retries": 0}`, false, false},
+		{`{"a": {"retries": 3}}`, `{"a": {}}`, false, false},
+		{`{"tags": ["x"]}`, `{}`, false, true},
+		{`{"tags": []}`, `{}`, true, false},
+		{`{"opts": {"x": 1}}`, `{}`, false, true},
+		{`{"opts": {"x": 1, "y": 0}}`, `{}`, false, false},
+		{`{"opts": {"x": 1, "y": 0}}`, `{}`, true, true}, // within the default value, too
+		{`{"a": 0, "b": "", "c": false, "d": null, "e": [], "f": {}}`, `{}`, true, true},
+		{`{"a": 0, "b": "", "c": false, "d": null, "e": [], "f": {}}`, `{}`, false, false},
+		{`{"a": 1}`, `{}`, true, false},
+		{`{"a": " "}`, `{}`, true, false},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{
+			BasicEqualer:      TolerantBasicEqualer{},
+			MissingEqualsZero: tc.zero,
+			Defaults: map[string]interface{}{
+				"/retries": 3,
+				"/tags":    []string{"x"},
+				"/opts":    map[string]int{"x": 1},
+			},
+		}
+		actual, err := jd.Equal([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil || d.Modified() == tc.expected {
+			t.Errorf("[%v == %v] expected modified: %v; got %v (%v)", tc.a, tc.b, !tc.expected, d, err)
+		}
+		sd, err := jd.CompareReaders(strings.NewReader(tc.a), strings.NewReader(tc.b), 0)
+		if err != nil || sd.Modified() == tc.expected {
+			t.Errorf("[%v == %v] expected modified: %v; got %v (%v)", tc.a, tc.b, !tc.expected, sd, err)
+		}
+	}
+}
+
+func TestDefaults_errors(t *testing.T) {
+	type testCase struct {
+		defaults map[string]interface{}
+		expected string
+	}
+	tcs := []testCase{
+		{map[string]interface{}{"retries": 3}, `invalid path pattern "retries": must start with /`},
+		{map[string]interface{}{"/[": 3}, `invalid path pattern "/[": syntax error in pattern`},
+	}
+	for _, tc := range tcs {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Defaults: tc.defaults}
+		if _, err := e.Equal(1, 1); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.defaults, tc.expected, err)
+		}
+		jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Defaults: tc.defaults}
+		if _, err := jd.Equal([]byte("1"), []byte("1")); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.defaults, tc.expected, err)
+		}
+		if _, err := jd.Compare([]byte("1"), []byte("1")); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.defaults, tc.expected, err)
+		}
+		if _, err := jd.CompareReaders(strings.NewReader("1"), strings.NewReader("1"), 0); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.defaults, tc.expected, err)
+		}
+	}
+
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Defaults: map[string]interface{}{"/a": func() {}}}
+	expected := `invalid default value for "/a": json: unsupported type: func()`
+	if _, err := jd.Compare([]byte("1"), []byte("1")); err == nil || err.Error() != expected {
+		t.Errorf("expected %v; got %v", expected, err)
+	}
+}
@@ -0,0 +1,120 @@
+package compare
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// A Path identifies a value nested within another value. Each element of a
+// Path is either a gojsondiff.Name (identifying a value within an object, map
+// or struct) or a gojsondiff.Index (identifying a value within an array or slice).
+// The empty Path identifies the outermost value.
+type Path []gojsondiff.Position
+
+// Pointer returns the path as a JSON Pointer (https://tools.ietf.org/html/rfc6901),
+// e.g. "/a/0/b".
+func (p Path) Pointer() string {
+	var buf bytes.Buffer
+	for _, pos := range p {
+		buf.WriteByte('/')
+		buf.WriteString(pointerEscaper.Replace(pos.String()))
+	}
+	return buf.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// String returns the path in a notation similar to Go and JavaScript
+// expressions, e.g. "a[0].b" or `a["b c"]`.
+func (p Path) String() string {
+	var buf bytes.Buffer
+	for i, pos := range p {
+		switch pos := pos.(type) {
+		case gojsondiff.Index:
+			buf.WriteString("[" + pos.String() + "]")
+		default:
+			name := pos.String()
+			if !isIdentifier(name) {
+				buf.WriteString("[" + strconv.Quote(name) + "]")
+				continue
+			}
+			if i > 0 {
+				buf.WriteByte('.')
+			}
+			buf.WriteString(name)
+		}
+	}
+	return buf.String()
+}
+
+// clone returns a copy of the path that isn't affected by appending to the original.
+func (p Path) clone() Path {
+	return append(Path(nil), p...)
+}
+
+// child returns a new path identifying a value nested within the value identified by p.
+func (p Path) child(pos gojsondiff.Position) Path {
+	return append(p[:len(p):len(p)], pos)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// A Tolerance describes two basic values that aren't identical, but were
+// considered equal.
+type Tolerance struct {
+	// Path specifies where the values are located.
+	Path Path
+	// Left is the value on the left side of the comparison.
+	Left interface{}
+	// Right is the value on the right side of the comparison.
+	Right interface{}
+	// Rule describes why the values were considered equal.
+	Rule string
+}
+
+// Operation specifies how a value differs between the left and the right side
+// of a comparison. The names follow JSON Patch (https://tools.ietf.org/html/rfc6902).
+type Operation string
+
+const (
+	// OperationAdd marks values that only exist on the right side.
+	OperationAdd Operation = "add"
+	// OperationRemove marks values that only exist on the left side.
+	OperationRemove Operation = "remove"
+	// OperationReplace marks values that were replaced by other values.
+	OperationReplace Operation = "replace"
+)
+
+// A Difference describes a value that differs between the left and the right
+// side of a comparison.
+type Difference struct {
+	// Path specifies where the value is located.
+	Path Path
+	// Operation specifies how the value differs.
+	Operation Operation
+	// Left is the value on the left side of the comparison (nil for OperationAdd).
+	Left interface{}
+	// Right is the value on the right side of the comparison (nil for OperationRemove).
+	Right interface{}
+	// Comparator names the comparison that decided that the values differ,
+	// e.g. "Float64" for the BasicEqualer's Float64 function, or "type" if the
+	// values are of different types. It's empty for added and removed values.
+	Comparator string
+	// Message describes the difference in more detail, if the comparison
+	// that decided that the values differ provides such a description.
+	Message string
+}
@@ -0,0 +1,39 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/yudai/gojsondiff"
+)
+
+func TestPath(t *testing.T) {
+	type testCase struct {
+		path    Path
+		pointer string
+		str     string
+	}
+	tcs := []testCase{
+		{nil, "", ""},
+		{Path{gojsondiff.Name("a")}, "/a", "a"},
+		{Path{gojsondiff.Index(0)}, "/0", "[0]"},
+		{Path{gojsondiff.Name("a"), gojsondiff.Index(0), gojsondiff.Name("b_1")}, "/a/0/b_1", "a[0].b_1"},
+		{Path{gojsondiff.Name("a/b"), gojsondiff.Name("c~d")}, "/a~1b/c~0d", `["a/b"]["c~d"]`},
+		{Path{gojsondiff.Name(""), gojsondiff.Name("1a"), gojsondiff.Name("ä")}, "//1a/ä", `[""]["1a"].ä`},
+	}
+	for _, tc := range tcs {
+		if actual := tc.path.Pointer(); actual != tc.pointer {
+			t.Errorf("[%#v] expected pointer %q; got %q", tc.path, tc.pointer, actual)
+		}
+		if actual := tc.path.String(); actual != tc.str {
+			t.Errorf("[%#v] expected string %q; got %q", tc.path, tc.str, actual)
+		}
+	}
+
+	// children mustn't share memory with their siblings
+	p := make(Path, 1, 10)
+	p[0] = gojsondiff.Name("a")
+	c1, c2 := p.child(gojsondiff.Index(1)), p.child(gojsondiff.Index(2))
+	if c1.String() != "a[1]" || c2.String() != "a[2]" {
+		t.Errorf("expected a[1] and a[2]; got %v and %v", c1, c2)
+	}
+}
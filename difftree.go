@@ -0,0 +1,237 @@
+package compare
+
+import (
+	"github.com/yudai/gojsondiff"
+)
+
+// nodeKind specifies how a node of a diff tree differs between the left and
+// the right side of a comparison.
+type nodeKind int
+
+const (
+	// nodeSame marks values that are considered equal (but not necessarily identical).
+	nodeSame nodeKind = iota
+	// nodeChanged marks objects and arrays containing differences.
+	nodeChanged
+	// nodeAdded marks values that only exist on the right side.
+	nodeAdded
+	// nodeDeleted marks values that only exist on the left side.
+	nodeDeleted
+	// nodeModified marks values that were replaced by other values.
+	nodeModified
+)
+
+// diffNode is a node of a diff tree, which merges the left and the right side
+// of a comparison with the deltas between them. Unlike the deltas, the tree
+// also contains the values that are the same on both sides, which makes it
+// easy to render the differences in context.
+type diffNode struct {
+	kind nodeKind
+	path Path
+	// left is the value on the left side (if any).
+	left interface{}
+	// right is the value on the right side (if any).
+	right interface{}
+	// children contains the nodes of the elements of objects and arrays, in
+	// the order in which they should be rendered. Modified nodes have no
+	// children; use valueTree() to render their values.
+	children []*diffNode
+	// textDiff is true if the node was created from a TextDiff.
+	textDiff bool
+	// tolerance is set if the node represents values that aren't identical,
+	// but were considered equal.
+	tolerance *Tolerance
+}
+
+// position returns the position of the node within its parent (if any).
+func (n *diffNode) position() gojsondiff.Position {
+	if len(n.path) == 0 {
+		return nil
+	}
+	return n.path[len(n.path)-1]
+}
+
+// value returns the value of the node that should be rendered for added,
+// deleted, changed and unchanged nodes.
+func (n *diffNode) value() interface{} {
+	if n.kind == nodeAdded {
+		return n.right
+	}
+	return n.left
+}
+
+// newDiffTree returns the root of the diff tree for a JSONDiff.
+func newDiffTree(d *JSONDiff) *diffNode {
+	b := treeBuilder{
+		tolerances: make(map[string]*Tolerance, len(d.tolerances)),
+		subset:     d.subset,
+		matching:   d.matching,
+	}
+	for i := range d.tolerances {
+		b.tolerances[d.tolerances[i].Path.Pointer()] = &d.tolerances[i]
+	}
+	var delta gojsondiff.Delta
+	if len(d.ds) > 0 {
+		delta = d.ds[0] // the delta for the explicit root
+	}
+	return b.build(nil, d.left["$"], d.right, delta)
+}
+
+type treeBuilder struct {
+	tolerances map[string]*Tolerance
+	subset     bool
+	matching   ArrayMatching
+}
+
+// build returns the diff tree for two values and the delta between them (if any).
+// cf. https://github.com/yudai/gojsondiff/blob/master/formatter/ascii.go#L118-L202
+func (b *treeBuilder) build(path Path, left, right interface{}, delta gojsondiff.Delta) *diffNode {
+	n := &diffNode{kind: nodeChanged, path: path, left: left, right: right}
+
+	switch d := delta.(type) {
+	case nil:
+		n.kind = nodeSame
+		n.tolerance = b.tolerances[path.Pointer()]
+		n.children = b.children(path, left, right, nil)
+	case *gojsondiff.Object:
+		n.children = b.children(path, left, right, deltasByPosition(d.Deltas))
+	case *gojsondiff.Array:
+		n.children = b.children(path, left, right, deltasByPosition(d.Deltas))
+	case *gojsondiff.TextDiff:
+		n.kind = nodeModified
+		n.textDiff = true
+	default: // Modified
+		n.kind = nodeModified
+	}
+
+	return n
+}
+
+// children returns the nodes of the elements of two objects or arrays, given
+// the deltas between the elements (nil if the values are considered equal).
+// Elements without deltas are considered equal, even if they only exist on
+// one side (e.g. because of MissingEqualsNull); like values that were
+// tolerated, they're represented by unchanged nodes, which then contain the
+// existing value on both sides. If the values aren't of the same type (e.g.
+// because of NullEqualsEmpty), there are no children.
+func (b *treeBuilder) children(path Path, left, right interface{}, ds map[gojsondiff.Position]gojsondiff.Delta) []*diffNode {
+	var children []*diffNode
+	switch l := left.(type) {
+	case map[string]interface{}:
+		r, ok := right.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(l) {
+			pos := gojsondiff.Name(key)
+			rv, ok := r[key]
+			switch _, changed := ds[pos]; {
+			case ok:
+				children = append(children, b.build(path.child(pos), l[key], rv, ds[pos]))
+			case changed:
+				children = append(children, valueTree(nodeDeleted, path.child(pos), l[key]))
+			default:
+				children = append(children, b.build(path.child(pos), l[key], l[key], nil))
+			}
+		}
+		// like gojsondiff's formatters, list added keys after all other keys
+		for _, key := range sortedKeys(r) {
+			pos := gojsondiff.Name(key)
+			if _, ok := l[key]; ok || b.subset {
+				continue
+			}
+			if _, changed := ds[pos]; changed {
+				children = append(children, valueTree(nodeAdded, path.child(pos), r[key]))
+			} else {
+				children = append(children, b.build(path.child(pos), r[key], r[key], nil))
+			}
+		}
+	case []interface{}:
+		r, ok := right.([]interface{})
+		if !ok {
+			return nil
+		}
+		if b.subset {
+			return b.subsetChildren(path, l, r, ds)
+		}
+		for i := 0; i < len(l) || i < len(r); i++ {
+			pos := gojsondiff.Index(i)
+			switch {
+			case i >= len(r):
+				children = append(children, valueTree(nodeDeleted, path.child(pos), l[i]))
+			case i >= len(l):
+				children = append(children, valueTree(nodeAdded, path.child(pos), r[i]))
+			default:
+				children = append(children, b.build(path.child(pos), l[i], r[i], ds[pos]))
+			}
+		}
+	}
+	return children
+}
+
+// subsetChildren returns the nodes of the elements of two arrays if the left
+// array only needs to be a subset of the right array. Right elements that
+// aren't matched with left elements are omitted. Unless the elements are
+// matched by index, left elements that were matched are represented by
+// unchanged nodes that contain the left element on both sides.
+func (b *treeBuilder) subsetChildren(path Path, l, r []interface{}, ds map[gojsondiff.Position]gojsondiff.Delta) []*diffNode {
+	children := make([]*diffNode, 0, len(l))
+	for i := range l {
+		pos := gojsondiff.Index(i)
+		switch d := ds[pos]; {
+		case isDeleted(d):
+			children = append(children, valueTree(nodeDeleted, path.child(pos), l[i]))
+		case b.matching == IndexMatching:
+			children = append(children, b.build(path.child(pos), l[i], r[i], d))
+		default:
+			children = append(children, b.build(path.child(pos), l[i], l[i], nil))
+		}
+	}
+	return children
+}
+
+// isDeleted determines if a Delta represents a deleted value.
+func isDeleted(d gojsondiff.Delta) bool {
+	_, ok := d.(*gojsondiff.Deleted)
+	return ok
+}
+
+// valueTree returns a diff tree in which all nodes are of the same kind
+// (nodeAdded or nodeDeleted), representing a value that only exists on one side.
+func valueTree(kind nodeKind, path Path, v interface{}) *diffNode {
+	n := &diffNode{kind: kind, path: path}
+	if kind == nodeAdded {
+		n.right = v
+	} else {
+		n.left = v
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			n.children = append(n.children, valueTree(kind, path.child(gojsondiff.Name(key)), v[key]))
+		}
+	case []interface{}:
+		for i, item := range v {
+			n.children = append(n.children, valueTree(kind, path.child(gojsondiff.Index(i)), item))
+		}
+	}
+	return n
+}
+
+// deltasByPosition indexes deltas by their (post-)position.
+func deltasByPosition(ds []gojsondiff.Delta) map[gojsondiff.Position]gojsondiff.Delta {
+	m := make(map[gojsondiff.Position]gojsondiff.Delta, len(ds))
+	for _, d := range ds {
+		m[deltaPosition(d)] = d
+	}
+	return m
+}
+
+// isContainer returns true for objects and arrays.
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// equalNilOrEmpty compares two slices or maps of which exactly one is nil.
+// They're only considered equal if both are empty and the DeepEqualer
+// specifies NilEqualsEmpty.
+func (c *deepComparison) equalNilOrEmpty(v1, v2 reflect.Value) bool {
+	if c.NilEqualsEmpty && v1.Len() == 0 && v2.Len() == 0 {
+		c.tolerate(v1, v2, "DeepEqualer.NilEqualsEmpty")
+		return true
+	}
+	return c.differ(v1, v2, "nil")
+}
+
+// equalNilOrZero compares two pointers of which exactly one is nil, if the
+// DeepEqualer specifies NilEqualsZero. The second return value is false if
+// the other pointer doesn't point to the zero value, in which case the caller
+// should compare the pointers like it otherwise would.
+func (c *deepComparison) equalNilOrZero(v1, v2 reflect.Value) (same, ok bool) {
+	if !c.NilEqualsZero {
+		return false, false
+	}
+	v := v1
+	if v1.IsNil() {
+		v = v2
+	}
+	if !v.Elem().IsZero() {
+		return false, false
+	}
+	c.tolerate(v1, v2, "DeepEqualer.NilEqualsZero")
+	return true, true
+}
+
+// tolerate records that two values that aren't identical were considered
+// equal according to the given rule.
+func (c *deepComparison) tolerate(v1, v2 reflect.Value, rule string) {
+	if c.collect {
+		c.tolerances = append(c.tolerances, Tolerance{
+			Path:  c.path.clone(),
+			Left:  valueOf(v1),
+			Right: valueOf(v2),
+			Rule:  rule,
+		})
+	}
+}
+
+// missingRule returns the rule by which the value at p is considered equal to
+// a missing value (i.e. the value of a key that the other object doesn't have),
+// or "" if it isn't. If there's a default value for p, the value must be equal
+// to it.
+func (c *jsonComparison) missingRule(p Path, v interface{}) string {
+	if pd, ok := lookupDefault(c.defaults, p); ok {
+		m := &jsonComparison{JSONDiffer: c.JSONDiffer, path: p.clone(), counters: &comparisonCounters{}, defaults: c.defaults}
+		if m.equal(pd.value, v) {
+			return fmt.Sprintf("JSONDiffer.Defaults[%q]", pd.pattern)
+		}
+		return ""
+	}
+	switch {
+	case c.MissingEqualsNull && v == nil:
+		return "JSONDiffer.MissingEqualsNull"
+	case c.MissingEqualsEmpty && isEmptyContainer(v):
+		return "JSONDiffer.MissingEqualsEmpty"
+	case c.MissingEqualsZero && isZeroJSON(v):
+		return "JSONDiffer.MissingEqualsZero"
+	}
+	return ""
+}
+
+// nullRule returns the rule by which two values of different types are
+// considered equal, or "" if they aren't.
+func (c *jsonComparison) nullRule(left, right interface{}) string {
+	if c.NullEqualsEmpty && (left == nil && isEmptyContainer(right) || right == nil && isEmptyContainer(left)) {
+		return "JSONDiffer.NullEqualsEmpty"
+	}
+	return ""
+}
+
+// tolerate records that two values that aren't identical were considered
+// equal according to the given rule.
+func (c *jsonComparison) tolerate(path Path, left, right interface{}, rule string) {
+	c.tolerances = append(c.tolerances, Tolerance{Path: path.clone(), Left: left, Right: right, Rule: rule})
+}
+
+// isEmptyContainer returns true if a decoded JSON value is an empty array or object.
+func isEmptyContainer(v interface{}) bool {
+	switch v := v.(type) {
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// isZeroJSON returns true if a decoded JSON value is null, false, 0, "" or an
+// empty array or object.
+func isZeroJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	}
+	return isEmptyContainer(v)
+}
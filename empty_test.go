@@ -0,0 +1,225 @@
+package compare
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func ExampleJSONDiffer_NullEqualsEmpty() {
+	jd := JSONDiffer{
+		BasicEqualer:      TolerantBasicEqualer{},
+		MissingEqualsNull: true,
+		NullEqualsEmpty:   true,
+	}
+	d, err := jd.Compare(
+		[]byte(`{"name": "a", "tags": null, "owner": null}`),
+		[]byte(`{"name": "a", "tags": [], "labels": {}}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.Operation, diff.Left, diff.Right)
+	}
+	for _, t := range d.Tolerances() {
+		fmt.Println(t.Path, t.Rule)
+	}
+	// Output:
+	// labels add <nil> map[]
+	// owner JSONDiffer.MissingEqualsNull
+	// tags JSONDiffer.NullEqualsEmpty
+}
+
+func TestDeepEqualer_Equal_nilOrEmpty(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	type testCase struct {
+		a        interface{}
+		b        interface{}
+		empty    bool
+		zero     bool
+		expected bool
+	}
+	tcs := []testCase{
+		{[]int(nil), []int{}, false, false, false},
+		{[]int(nil), []int{}, true, false, true},
+		{[]int{}, []int(nil), true, false, true},
+		{[]int(nil), []int{1}, true, false, false},
+		{map[string]int(nil), map[string]int{}, false, false, false},
+		{map[string]int(nil), map[string]int{}, true, false, true},
+		{map[string]int(nil), map[string]int{"a": 1}, true, false, false},
+		{[][]int{nil, {}}, [][]int{{}, nil}, true, false, true},
+		{(*point)(nil), &point{}, false, false, false},
+		{(*point)(nil), &point{}, false, true, true},
+		{&point{}, (*point)(nil), false, true, true},
+		{(*point)(nil), &point{1, 0}, false, true, false},
+		{(*[]int)(nil), new([]int), false, true, true},
+		{(*[]int)(nil), &[]int{}, true, true, false}, // an empty slice isn't the zero value
+		{&[]int{}, &[]int{}, false, false, true},
+		{interface{}(nil), []int{}, true, true, false},
+	}
+	for _, tc := range tcs {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, NilEqualsEmpty: tc.empty, NilEqualsZero: tc.zero}
+		actual, err := e.Equal(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_nilOrEmpty(t *testing.T) {
+	type item struct {
+		Tags   []string
+		Labels map[string]string
+		Parent *item
+		Codes  []int `compare:"unordered"`
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, NilEqualsEmpty: true, NilEqualsZero: true}
+	d, err := e.Compare(
+		item{Tags: nil, Labels: map[string]string{}, Parent: &item{}, Codes: nil},
+		item{Tags: []string{}, Labels: nil, Parent: nil, Codes: []int{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Modified() {
+		t.Errorf("expected no differences; got %v", describeDifferences(d.Differences()))
+	}
+	expected := "[/Codes [] [] DeepEqualer.NilEqualsEmpty; /Labels map[] map[] DeepEqualer.NilEqualsEmpty; " +
+		"/Parent &{[] map[] <nil> []} <nil> DeepEqualer.NilEqualsZero; /Tags [] [] DeepEqualer.NilEqualsEmpty]"
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestJSONDiffer_Equal_nullOrEmpty(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		missing  bool
+		empty    bool
+		null     bool
+		expected bool
+	}
+	tcs := []testCase{
+		{`{"a": null}`, `{}`, false, false, false, false},
+		{`{"a": null}`, `{}`, true, false, false, true},
+		{`{}`, `{"a": null}`, true, false, false, true},
+		{`{"a": null}`, `{}`, false, true, false, false},
+		{`{"a": []}`, `{}`, false, false, false, false},
+		{`{"a": []}`, `{}`, true, false, false, false},
+		{`{"a": []}`, `{}`, false, true, false, true},
+		{`{}`, `{"a": {}}`, false, true, false, true},
+		{`{}`, `{"a": {"b": null}}`, true, true, true, false},
+		{`{"a": 1}`, `{"b": null}`, true, false, false, false},
+		{`{"a": null}`, `{"b": null}`, true, false, false, true},
+		{`null`, `[]`, false, false, false, false},
+		{`null`, `[]`, false, false, true, true},
+		{`{}`, `null`, false, false, true, true},
+		{`[null]`, `[{}]`, false, false, true, true},
+		{`null`, `[1]`, false, false, true, false},
+		{`null`, `""`, true, true, true, false},
+		{`{"a": null}`, `{"a": []}`, true, true, false, false}, // missing isn't transitive
+		{`{"a": null, "b": 1}`, `{"b": 2}`, true, false, false, false},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{
+			BasicEqualer:       TolerantBasicEqualer{},
+			MissingEqualsNull:  tc.missing,
+			MissingEqualsEmpty: tc.empty,
+			NullEqualsEmpty:    tc.null,
+		}
+		actual, err := jd.Equal([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+		// Compare must agree with Equal
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil || d.Modified() == tc.expected {
+			t.Errorf("[%v == %v] expected modified: %v; got %v (%v)", tc.a, tc.b, !tc.expected, d, err)
+		}
+		// and so must CompareReaders
+		sd, err := jd.CompareReaders(strings.NewReader(tc.a), strings.NewReader(tc.b), 0)
+		if err != nil || sd.Modified() == tc.expected {
+			t.Errorf("[%v == %v] expected modified: %v; got %v (%v)", tc.a, tc.b, !tc.expected, sd, err)
+		}
+	}
+}
+
+func TestJSONDiffer_Compare_nullOrEmpty(t *testing.T) {
+	jd := JSONDiffer{
+		BasicEqualer:       TolerantBasicEqualer{},
+		MissingEqualsNull:  true,
+		MissingEqualsEmpty: true,
+		NullEqualsEmpty:    true,
+	}
+	a := `{"a": null, "c": {"d": []}, "e": null, "f": 1}`
+	b := `{"b": {}, "c": {"d": null}, "e": [], "g": null}`
+	expected := "[/a <nil> <nil> JSONDiffer.MissingEqualsNull; /b <nil> map[] JSONDiffer.MissingEqualsEmpty; " +
+		"/c/d [] <nil> JSONDiffer.NullEqualsEmpty; /e <nil> [] JSONDiffer.NullEqualsEmpty; " +
+		"/g <nil> <nil> JSONDiffer.MissingEqualsNull]"
+	d, err := jd.Compare([]byte(a), []byte(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := describeDifferences(d.Differences()); actual != "[/f remove 1 <nil>]" {
+		t.Errorf("expected %v; got %v", "[/f remove 1 <nil>]", actual)
+	}
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestJSONDiff_Format_nullOrEmpty(t *testing.T) {
+	// tolerated missing and empty values are unchanged, not deleted or added
+	type testCase struct {
+		a       string
+		b       string
+		missing bool
+		empty   bool
+		null    bool
+		format  string
+		stats   string // added, deleted, modified, unchanged and tolerated leaves
+	}
+	tcs := []testCase{
+		{`{}`, `null`, false, false, true, " {\n }\n", "0 0 0 1 1"},
+		{`null`, `[]`, false, false, true, "  null\n", "0 0 0 1 1"},
+		{`{"a": {}}`, `{}`, false, true, false, " {\n   \"a\": {\n   }\n }\n", "0 0 0 1 1"},
+		{`{"a": [], "b": 1}`, `{"b": 2}`, false, true, false, " {\n   \"a\": [\n   ],\n-  \"b\": 1\n+  \"b\": 2\n }\n", "0 0 1 1 1"},
+		{`{"b": null}`, `{}`, true, false, false, " {\n   \"b\": null\n }\n", "0 0 0 1 1"},
+		{`{"a": 1}`, `{"a": 1, "b": null}`, true, false, false, " {\n   \"a\": 1\n }\n", "0 0 0 2 1"},
+		{`{"a": 1, "b": null}`, `{"a": 2}`, true, false, false, " {\n-  \"a\": 1,\n+  \"a\": 2,\n   \"b\": null\n }\n", "0 0 1 1 1"},
+		{`{"a": [null, {}]}`, `{"a": [{}, null], "c": 1}`, false, false, true, " {\n   \"a\": [\n     0: null,\n     1: {\n     }\n   ]\n+  \"c\": 1\n }\n", "1 0 0 2 2"},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{
+			BasicEqualer:       TolerantBasicEqualer{},
+			MissingEqualsNull:  tc.missing,
+			MissingEqualsEmpty: tc.empty,
+			NullEqualsEmpty:    tc.null,
+		}
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, err := d.Format(false); err != nil || actual != tc.format {
+			t.Errorf("[%v == %v] expected %q; got %q (%v)", tc.a, tc.b, tc.format, actual, err)
+		}
+		s := d.Stats()
+		if actual := fmt.Sprint(s.Added, s.Deleted, s.Modified, s.Unchanged, s.Tolerated); actual != tc.stats {
+			t.Errorf("[%v == %v] expected stats %v; got %v", tc.a, tc.b, tc.stats, actual)
+		}
+		if _, err := d.FormatWithOptions(FormatOptions{SideBySide: true, MaxWidth: 80}); err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		}
+		if err := d.WriteHTML(ioutil.Discard, HTMLOptions{}); err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		}
+	}
+}
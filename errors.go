@@ -0,0 +1,80 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrUnexportedField matches every *UnexportedFieldError (when using errors.Is).
+	ErrUnexportedField = errors.New("unexported field")
+	// ErrUnsupportedKind matches every *UnsupportedKindError (when using errors.Is).
+	ErrUnsupportedKind = errors.New("unsupported kind")
+	// ErrInvalidKey matches every *KeyError (when using errors.Is).
+	ErrInvalidKey = errors.New("invalid key")
+)
+
+// An UnexportedFieldError is returned by a DeepEqualer if two values can only
+// be compared with reflect.DeepEqual() (e.g. channels and functions), but
+// they were obtained by accessing unexported struct fields.
+type UnexportedFieldError struct {
+	// Path specifies where the values are located.
+	Path Path
+	// Type is the type of the values.
+	Type reflect.Type
+}
+
+func (e *UnexportedFieldError) Error() string {
+	return fmt.Sprintf("cannot compare %v at %q: obtained from unexported field", e.Type, e.Path.Pointer())
+}
+
+// Is returns true if target is ErrUnexportedField.
+func (e *UnexportedFieldError) Is(target error) bool {
+	return target == ErrUnexportedField
+}
+
+// An UnsupportedKindError is returned by a DeepEqualer if it encounters values
+// of a kind it doesn't know how to compare.
+type UnsupportedKindError struct {
+	// Path specifies where the values are located.
+	Path Path
+	// Type is the type of the values.
+	Type reflect.Type
+}
+
+func (e *UnsupportedKindError) Error() string {
+	return fmt.Sprintf("cannot compare %v at %q: unsupported kind %v", e.Type, e.Path.Pointer(), e.Type.Kind())
+}
+
+// Is returns true if target is ErrUnsupportedKind.
+func (e *UnsupportedKindError) Is(target error) bool {
+	return target == ErrUnsupportedKind
+}
+
+// A KeyError is returned by a DeepEqualer if the elements of a slice or array
+// tagged with a key can't be identified by their keys, because an element is
+// a nil pointer or two elements have the same key.
+type KeyError struct {
+	// Path specifies where the slice or array is located.
+	Path Path
+	// Index is the index of the element.
+	Index int
+	// Key is the string representation of the element's key (unless the
+	// element is nil).
+	Key string
+	// Nil specifies whether the element is a nil pointer.
+	Nil bool
+}
+
+func (e *KeyError) Error() string {
+	if e.Nil {
+		return fmt.Sprintf("element %d at %q is nil, so it has no key", e.Index, e.Path.Pointer())
+	}
+	return fmt.Sprintf("duplicate key %s at %q", e.Key, e.Path.Pointer())
+}
+
+// Is returns true if target is ErrInvalidKey.
+func (e *KeyError) Is(target error) bool {
+	return target == ErrInvalidKey
+}
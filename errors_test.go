@@ -0,0 +1,143 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yudai/gojsondiff"
+)
+
+func ExampleUnexportedFieldError() {
+	type handler struct {
+		fn func()
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	_, err := e.Equal(map[string]handler{"a": {}}, map[string]handler{"a": {}})
+	var ufe *UnexportedFieldError
+	if errors.As(err, &ufe) {
+		fmt.Println(ufe.Path, ufe.Type)
+	}
+	fmt.Println(errors.Is(err, ErrUnexportedField), errors.Is(err, ErrUnsupportedKind))
+	// Output:
+	// a.fn func()
+	// true false
+}
+
+func TestUnexportedFieldError(t *testing.T) {
+	type unexported struct {
+		ch chan int
+	}
+	type wrapper struct {
+		Values []unexported
+	}
+	ch := make(chan int)
+	a := wrapper{Values: []unexported{{ch}, {ch}}}
+	b := wrapper{Values: []unexported{{ch}, {nil}}}
+	expected := &UnexportedFieldError{Path: Path{gojsondiff.Name("Values"), gojsondiff.Index(0), gojsondiff.Name("ch")}, Type: reflect.TypeOf(ch)}
+
+	for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Parallelism: p}
+		_, err := e.Equal(a, b)
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("[%+v] expected %v; got %v", p, expected, err)
+		}
+		_, err = e.Compare(a, b)
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("[%+v] expected %v; got %v", p, expected, err)
+		}
+	}
+}
+
+func TestUnsupportedKindError(t *testing.T) {
+	err := error(&UnsupportedKindError{Path: Path{gojsondiff.Name("a")}, Type: reflect.TypeOf(0)})
+	if expected := `cannot compare int at "/a": unsupported kind int`; err.Error() != expected {
+		t.Errorf("expected %v; got %v", expected, err)
+	}
+	if !errors.Is(err, ErrUnsupportedKind) || errors.Is(err, ErrUnexportedField) {
+		t.Errorf("expected error to match %v only", ErrUnsupportedKind)
+	}
+}
+
+func TestKeyError(t *testing.T) {
+	type item struct {
+		ID int
+	}
+	type wrapper struct {
+		Items []*item `compare:"key=ID"`
+	}
+	type testCase struct {
+		a        wrapper
+		expected *KeyError
+		message  string
+	}
+	items := Path{gojsondiff.Name("Items")}
+	tcs := []testCase{
+		{wrapper{[]*item{{1}, nil}}, &KeyError{Path: items, Index: 1, Nil: true}, `element 1 at "/Items" is nil, so it has no key`},
+		{wrapper{[]*item{{1}, {2}, {1}}}, &KeyError{Path: items, Index: 2, Key: "1"}, `duplicate key 1 at "/Items"`},
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		_, err := e.Equal(tc.a, tc.a)
+		if !reflect.DeepEqual(err, tc.expected) || err.Error() != tc.message {
+			t.Errorf("[%v] expected %v; got %v", tc.message, tc.expected, err)
+		}
+		if !errors.Is(err, ErrInvalidKey) || errors.Is(err, ErrUnsupportedKind) {
+			t.Errorf("[%v] expected error to match %v only", tc.message, ErrInvalidKey)
+		}
+	}
+}
+
+// panickyEqualer is a BasicEqualer that panics when comparing strings.
+type panickyEqualer struct {
+	TolerantBasicEqualer
+}
+
+func (panickyEqualer) String(a, b string) bool {
+	panic("boom: " + a)
+}
+
+func TestDeepEqualer_Equal_panic(t *testing.T) {
+	// panics in a BasicEqualer must not be mistaken for errors
+	a, b := []string{"x", "y", "z"}, []string{"x", "y", "z"}
+	for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+		e := DeepEqualer{BasicEqualer: panickyEqualer{}, Parallelism: p}
+		for _, compare := range []func() error{
+			func() error { _, err := e.Equal(a, b); return err },
+			func() error { _, err := e.Compare(a, b); return err },
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r != "boom: x" {
+						t.Errorf("[%+v] expected panic %q; got %v", p, "boom: x", r)
+					}
+				}()
+				err := compare()
+				t.Errorf("[%+v] expected panic; got %v", p, err)
+			}()
+		}
+	}
+}
+
+func TestJSONDiffer_Compare_panic(t *testing.T) {
+	// panics in worker goroutines are raised again by the calling goroutine
+	a, b := []byte(`["x", "y", "z", "w"]`), []byte(`["a", "b", "c", "d"]`)
+	for _, p := range []Parallelism{{}, {Workers: 4, Threshold: 2}} {
+		jd := JSONDiffer{BasicEqualer: panickyEqualer{}, Parallelism: p}
+		for _, compare := range []func() error{
+			func() error { _, err := jd.Equal(a, b); return err },
+			func() error { _, err := jd.Compare(a, b); return err },
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r != "boom: x" {
+						t.Errorf("[%+v] expected panic %q; got %v", p, "boom: x", r)
+					}
+				}()
+				err := compare()
+				t.Errorf("[%+v] expected panic; got %v", p, err)
+			}()
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package compare
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// FieldFilter specifies which struct fields and map entries a DeepEqualer
+// ignores, e.g. fields of generated types that can't be tagged. A field or map
+// entry is ignored if it matches any of the criteria.
+type FieldFilter struct {
+	// Paths are JSON Pointers (cf. Path.Pointer) identifying the fields and
+	// map entries to ignore. Each token may be a pattern as accepted by
+	// path.Match, e.g. "/Items/*/ResourceVersion".
+	Paths []string
+	// Names are the names of struct fields and the string representations of
+	// map keys to ignore wherever they occur, e.g. "XXX_unrecognized".
+	Names []string
+	// Types are the types of struct fields and map values to ignore. For
+	// values of interface types, the types of the underlying values are
+	// considered as well.
+	Types []reflect.Type
+	// Func returns true if a field or map entry should be ignored. For map
+	// entries that only exist on one side, the Value for the other side is
+	// the zero Value.
+	Func func(p Path, v1, v2 reflect.Value) bool
+}
+
+// fieldFilter is a FieldFilter prepared for matching.
+type fieldFilter struct {
+	fn    func(p Path, v1, v2 reflect.Value) bool
+	paths [][]string
+	names map[string]bool
+	types map[reflect.Type]bool
+}
+
+// compile prepares the FieldFilter for matching. It returns nil if the
+// FieldFilter doesn't ignore anything.
+func (f FieldFilter) compile() (*fieldFilter, error) {
+	if len(f.Paths) == 0 && len(f.Names) == 0 && len(f.Types) == 0 && f.Func == nil {
+		return nil, nil
+	}
+	ff := &fieldFilter{
+		fn:    f.Func,
+		names: make(map[string]bool, len(f.Names)),
+		types: make(map[reflect.Type]bool, len(f.Types)),
+	}
+	for _, p := range f.Paths {
+		tokens, err := compilePathPattern(p)
+		if err != nil {
+			return nil, err
+		}
+		ff.paths = append(ff.paths, tokens)
+	}
+	for _, name := range f.Names {
+		ff.names[name] = true
+	}
+	for _, t := range f.Types {
+		ff.types[t] = true
+	}
+	return ff, nil
+}
+
+// match returns true if the field or map entry identified by p should be
+// ignored. t is the type of the field or of the map's values.
+func (f *fieldFilter) match(p Path, t reflect.Type, v1, v2 reflect.Value) bool {
+	if f == nil {
+		return false
+	}
+	if f.names[p[len(p)-1].String()] || f.types[t] || f.dynamicType(v1) || f.dynamicType(v2) {
+		return true
+	}
+	for _, tokens := range f.paths {
+		if matchTokens(tokens, p) {
+			return true
+		}
+	}
+	return f.fn != nil && f.fn(p, v1, v2)
+}
+
+// dynamicType returns true if v is a non-nil interface value whose underlying
+// value is of one of the ignored types.
+func (f *fieldFilter) dynamicType(v reflect.Value) bool {
+	return v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() && f.types[v.Elem().Type()]
+}
+
+// compilePathPattern splits a path pattern (cf. FieldFilter.Paths) into its
+// unescaped tokens.
+func compilePathPattern(p string) ([]string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid path pattern %q: must start with /", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = pointerUnescaper.Replace(token)
+		if _, err := path.Match(tokens[i], ""); err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %v", p, err)
+		}
+	}
+	return tokens, nil
+}
+
+// matchTokens returns true if p matches the tokens of a path pattern.
+func matchTokens(tokens []string, p Path) bool {
+	if len(tokens) != len(p) {
+		return false
+	}
+	for i, token := range tokens {
+		if ok, _ := path.Match(token, p[i].String()); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ignored returns true if the value at the given position within the values
+// that are currently being compared should be ignored.
+func (c *deepComparison) ignored(pos gojsondiff.Position, t reflect.Type, v1, v2 reflect.Value) bool {
+	return c.filter != nil && c.filter.match(c.path.child(pos), t, v1, v2)
+}
@@ -0,0 +1,169 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleFieldFilter() {
+	type metadata struct {
+		Name            string
+		ResourceVersion string
+		Created         time.Time
+	}
+	type resource struct {
+		Metadata         metadata
+		Labels           map[string]string
+		XXX_unrecognized []byte
+	}
+	e := DeepEqualer{
+		BasicEqualer: TolerantBasicEqualer{},
+		Ignore: FieldFilter{
+			Paths: []string{"/Labels/internal.*"},
+			Names: []string{"XXX_unrecognized", "ResourceVersion"},
+			Types: []reflect.Type{reflect.TypeOf(time.Time{})},
+		},
+	}
+	d, err := e.Compare(
+		resource{metadata{"a", "1", time.Now()}, map[string]string{"app": "web", "internal.hash": "x"}, []byte{1}},
+		resource{metadata{"b", "2", time.Now()}, map[string]string{"app": "api", "internal.seen": "y"}, nil})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.Left, diff.Right)
+	}
+	// Output:
+	// Metadata.Name a b
+	// Labels.app web api
+}
+
+func TestDeepEqualer_Equal_ignore(t *testing.T) {
+	type inner struct {
+		ID      int
+		Version int
+		Secret  chan int
+	}
+	type outer struct {
+		Inner  inner
+		Items  []inner
+		Values map[string]interface{}
+		Stamp  time.Time
+	}
+	base := outer{
+		Inner:  inner{ID: 1, Version: 1},
+		Items:  []inner{{ID: 2, Version: 1}, {ID: 3, Version: 1}},
+		Values: map[string]interface{}{"a": 1, "b": time.Unix(0, 0), "c": "x"},
+		Stamp:  time.Unix(0, 0),
+	}
+	type testCase struct {
+		filter   FieldFilter
+		change   func(*outer)
+		expected bool
+	}
+	tcs := []testCase{
+		{FieldFilter{}, func(o *outer) {}, true},
+		{FieldFilter{}, func(o *outer) { o.Inner.Version = 2 }, false},
+		{FieldFilter{Names: []string{"Version"}}, func(o *outer) { o.Inner.Version = 2; o.Items[1].Version = 2 }, true},
+		{FieldFilter{Names: []string{"Version"}}, func(o *outer) { o.Items[1].ID = 4 }, false},
+		{FieldFilter{Names: []string{"Secret"}}, func(o *outer) { o.Inner.Secret = make(chan int) }, true},
+		{FieldFilter{Names: []string{"c"}}, func(o *outer) { o.Values["c"] = "y" }, true},
+		{FieldFilter{Names: []string{"c"}}, func(o *outer) { delete(o.Values, "c") }, true},
+		{FieldFilter{Names: []string{"c"}}, func(o *outer) { o.Values["d"] = 1 }, false},
+		{FieldFilter{Names: []string{"d"}}, func(o *outer) { o.Values["d"] = 1 }, true},
+		{FieldFilter{Paths: []string{"/Items/*/Version"}}, func(o *outer) { o.Items[0].Version = 2 }, true},
+		{FieldFilter{Paths: []string{"/Items/*/Version"}}, func(o *outer) { o.Inner.Version = 2 }, false},
+		{FieldFilter{Paths: []string{"/Items/1"}}, func(o *outer) { o.Items[1].ID = 4 }, false}, // only fields and map entries
+		{FieldFilter{Paths: []string{"/Values/[ab]"}}, func(o *outer) { o.Values["a"] = 2; o.Values["b"] = 3 }, true},
+		{FieldFilter{Paths: []string{"/Values/[ab]"}}, func(o *outer) { o.Values["c"] = 2 }, false},
+		{FieldFilter{Paths: []string{"/Inner"}}, func(o *outer) { o.Inner = inner{} }, true},
+		{FieldFilter{Types: []reflect.Type{reflect.TypeOf(time.Time{})}}, func(o *outer) {
+			o.Stamp = time.Now()
+			o.Values["b"] = time.Now()
+		}, true},
+		{FieldFilter{Types: []reflect.Type{reflect.TypeOf(time.Time{})}}, func(o *outer) { o.Values["b"] = 1 }, true}, // either side
+		{FieldFilter{Types: []reflect.Type{reflect.TypeOf(time.Time{})}}, func(o *outer) { o.Values["a"] = time.Now() }, true},
+		{FieldFilter{Types: []reflect.Type{reflect.TypeOf(inner{})}}, func(o *outer) { o.Inner.ID = 4 }, true},
+		{FieldFilter{Types: []reflect.Type{reflect.TypeOf(inner{})}}, func(o *outer) { o.Items[0].ID = 4 }, false},
+		{FieldFilter{Func: func(p Path, v1, v2 reflect.Value) bool {
+			return strings.HasPrefix(p.Pointer(), "/Items/") && p[len(p)-1].String() == "ID"
+		}}, func(o *outer) { o.Items[0].ID = 4 }, true},
+		{FieldFilter{Func: func(p Path, v1, v2 reflect.Value) bool {
+			return v1.IsValid() && fmt.Sprint(v1) == "1"
+		}}, func(o *outer) { o.Inner.Version = 2; o.Values["a"] = 3 }, true},
+		{FieldFilter{Func: func(p Path, v1, v2 reflect.Value) bool {
+			return !v1.IsValid()
+		}}, func(o *outer) { o.Values["d"] = 1 }, true},
+	}
+	for i, tc := range tcs {
+		changed := base
+		changed.Items = append([]inner(nil), base.Items...)
+		changed.Values = make(map[string]interface{})
+		for k, v := range base.Values {
+			changed.Values[k] = v
+		}
+		tc.change(&changed)
+		for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+			e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Ignore: tc.filter, Parallelism: p}
+			actual, err := e.Equal(base, changed)
+			if err != nil {
+				t.Errorf("[%d] %v", i, err)
+			} else if actual != tc.expected {
+				t.Errorf("[%d] expected %v; got %v", i, tc.expected, actual)
+			}
+			if d, err := e.Compare(base, changed); err != nil || d.Modified() == tc.expected {
+				t.Errorf("[%d] expected modified: %v; got %v (%v)", i, !tc.expected, d, err)
+			}
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_ignore(t *testing.T) {
+	type item struct {
+		ID    string
+		Count int
+		Note  string
+	}
+	type list struct {
+		Items []item `compare:"key=ID"`
+		Codes map[string]int
+	}
+	e := DeepEqualer{
+		BasicEqualer: TolerantBasicEqualer{},
+		Ignore:       FieldFilter{Paths: []string{"/Items/*/Note", "/Codes/x~1*"}},
+	}
+	d, err := e.Compare(
+		list{[]item{{"a", 1, "n"}, {"b", 2, "n"}}, map[string]int{"x/1": 1, "y": 1}},
+		list{[]item{{"b", 3, "m"}, {"a", 1, "m"}}, map[string]int{"x/2": 2, "y": 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[/Codes/y replace 1 2; /Items/b/Count replace 2 3]"
+	if actual := describeDifferences(d.Differences()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestDeepEqualer_Equal_ignoreErrors(t *testing.T) {
+	type testCase struct {
+		paths    []string
+		expected string
+	}
+	tcs := []testCase{
+		{[]string{"a"}, `invalid path pattern "a": must start with /`},
+		{[]string{"/a", "/b/["}, `invalid path pattern "/b/[": syntax error in pattern`},
+	}
+	for _, tc := range tcs {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Ignore: FieldFilter{Paths: tc.paths}}
+		if _, err := e.Equal(1, 1); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.paths, tc.expected, err)
+		}
+		if _, err := e.Compare(1, 1); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.paths, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/yudai/gojsondiff"
+	"golang.org/x/text/width"
+)
+
+// ellipsis marks truncated values and lines.
+const ellipsis = "…"
+
+// A Theme specifies how lines and highlighted text are colored. Each field
+// contains the parameters of an ANSI SGR escape sequence (e.g. "30;42" for
+// black text on a green background, or "38;5;22" for the 256-color palette
+// entry 22). Empty fields disable the respective coloring.
+type Theme struct {
+	// Same specifies the color of lines with unchanged values.
+	Same string
+	// Added specifies the color of lines with added values.
+	Added string
+	// Deleted specifies the color of lines with deleted values.
+	Deleted string
+	// Highlight specifies how changes within strings are highlighted. If it's
+	// empty, changes are highlighted as if coloring was disabled.
+	Highlight string
+	// HighlightOff specifies how highlighting is disabled without affecting
+	// the color of the line (e.g. "27" if Highlight is "7").
+	HighlightOff string
+}
+
+// Predefined themes.
+var (
+	// DefaultTheme uses the colors of gojsondiff's AsciiFormatter and
+	// highlights changes within strings in reverse video.
+	DefaultTheme = Theme{Added: "30;42", Deleted: "30;41", Highlight: "7", HighlightOff: "27"}
+	// Theme256 uses muted colors of the 256-color palette, dims unchanged lines,
+	// and highlights changes within strings in bold, underlined text.
+	Theme256 = Theme{
+		Same:         "38;5;244",
+		Added:        "38;5;22;48;5;194",
+		Deleted:      "38;5;88;48;5;224",
+		Highlight:    "1;4",
+		HighlightOff: "22;24",
+	}
+)
+
+// FormatOptions specifies how differences are formatted as text.
+// The zero value formats the differences like Format(false).
+type FormatOptions struct {
+	// Coloring specifies whether lines are colored. It's ignored if the
+	// environment variable NO_COLOR is set to a non-empty value
+	// (cf. https://no-color.org/).
+	Coloring bool
+	// Theme specifies the colors (DefaultTheme if nil).
+	Theme *Theme
+	// ChangesOnly specifies that unchanged values should be omitted, except for
+	// the objects and arrays containing changes and the context specified by
+	// Context. Consecutive omitted values are collapsed into a single line
+	// (e.g. "... 42 unchanged keys ...").
+	ChangesOnly bool
+	// Context specifies how many levels of unchanged values are shown next to
+	// changed values if ChangesOnly is set. With 0, all unchanged values are
+	// collapsed. With 1, unchanged siblings of changed values are shown, but
+	// their elements are collapsed (e.g. "key": {...}), and so on.
+	Context int
+	// MaxValueLength specifies how many characters of a string value are shown
+	// before it's truncated. If it's 0, values aren't truncated. Strings with
+	// highlighted changes are never truncated, because the changes may be
+	// anywhere within them.
+	MaxValueLength int
+	// MaxWidth specifies how many characters a line may have (including the
+	// leading marker and the indentation) before it's truncated. Wide
+	// characters (e.g. CJK characters and most emoji) count as two. If it's 0,
+	// lines aren't truncated, unless SideBySide is set.
+	MaxWidth int
+	// SideBySide specifies that the left and the right value are formatted in
+	// two columns, which together are at most MaxWidth characters wide. If
+	// MaxWidth is 0, the width of the terminal is used. If standard output
+	// isn't a terminal, it's specified by the environment variable COLUMNS
+	// (80 characters by default).
+	SideBySide bool
+}
+
+// FormatWithOptions returns a string representation of the differences
+// between two JSON values, formatted according to the given options.
+// Like Format, it highlights changes within strings represented by TextDiffs.
+func (d *JSONDiff) FormatWithOptions(opts FormatOptions) (string, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		opts.Coloring = false
+	}
+	if opts.Theme == nil {
+		opts.Theme = &DefaultTheme
+	}
+	f := textFormatter{opts: opts, granularity: d.granularity}
+	root := newDiffTree(d)
+	if opts.SideBySide {
+		sf := sideBySideFormatter{textFormatter: &f}
+		return sf.format(root), nil
+	}
+	if opts.ChangesOnly && root.kind == nodeSame {
+		f.same(root, 0, false, opts.Context)
+	} else {
+		f.node(root, 0, false)
+	}
+	return f.buf.String(), nil
+}
+
+// textFormatter formats a diff tree as text, similar to gojsondiff's
+// AsciiFormatter: each line starts with a marker (" ", "-" or "+"), followed
+// by two spaces of indentation per level.
+// cf. https://github.com/yudai/gojsondiff/blob/master/formatter/ascii.go
+type textFormatter struct {
+	opts        FormatOptions
+	granularity Granularity
+	buf         bytes.Buffer
+}
+
+// Markers at the start of lines.
+const (
+	markerSame    = " "
+	markerAdded   = "+"
+	markerDeleted = "-"
+)
+
+// node formats a node. comma specifies whether the node is followed by other
+// elements of its parent.
+func (f *textFormatter) node(n *diffNode, depth int, comma bool) {
+	switch n.kind {
+	case nodeSame:
+		f.value(markerSame, n, n.left, depth, comma, -1)
+	case nodeAdded:
+		f.value(markerAdded, n, n.right, depth, comma, -1)
+	case nodeDeleted:
+		f.value(markerDeleted, n, n.left, depth, comma, -1)
+	case nodeChanged:
+		f.line(markerSame, depth, f.key(n, n.left)+openText(n.left))
+		f.children(n, depth)
+		f.line(markerSame, depth, closeText(n.left)+commaText(comma))
+	case nodeModified:
+		if n.textDiff {
+			left, right := f.textDiffTexts(n)
+			f.line(markerDeleted, depth, left+commaText(comma))
+			f.line(markerAdded, depth, right+commaText(comma))
+			return
+		}
+		f.value(markerDeleted, n, n.left, depth, comma, -1)
+		f.value(markerAdded, n, n.right, depth, comma, -1)
+	}
+}
+
+// children formats the elements of a changed object or array. If ChangesOnly
+// is set, unchanged elements are formatted according to Context, or collapsed.
+func (f *textFormatter) children(n *diffNode, depth int) {
+	omitted := 0
+	for i, c := range n.children {
+		comma := hasLeftSibling(n.children[i+1:])
+		if !f.opts.ChangesOnly || c.kind != nodeSame {
+			f.omitted(n.left, omitted, depth+1)
+			omitted = 0
+			f.node(c, depth+1, comma)
+			continue
+		}
+		if f.opts.Context > 0 {
+			f.same(c, depth+1, comma, f.opts.Context-1)
+			continue
+		}
+		omitted++
+	}
+	f.omitted(n.left, omitted, depth+1)
+}
+
+// same formats an unchanged node, showing the given number of levels of its elements.
+func (f *textFormatter) same(n *diffNode, depth int, comma bool, levels int) {
+	f.value(markerSame, n, n.left, depth, comma, levels)
+}
+
+// value formats a value that only needs to be shown on one side. levels
+// specifies how many levels of elements of objects and arrays are shown
+// (all levels if it's negative).
+func (f *textFormatter) value(marker string, n *diffNode, v interface{}, depth int, comma bool, levels int) {
+	for _, l := range f.valueLines(n, v, depth, comma, levels) {
+		f.line(marker, l.depth, l.text)
+	}
+}
+
+// textLine is a line of text at a certain depth, which may still contain line breaks.
+type textLine struct {
+	depth int
+	text  string
+}
+
+// valueLines returns the lines representing a value (cf. value()).
+func (f *textFormatter) valueLines(n *diffNode, v interface{}, depth int, comma bool, levels int) []textLine {
+	key := f.key(n, v)
+	var lines []textLine
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if levels == 0 && len(v) > 0 {
+			return []textLine{{depth, key + "{...}" + commaText(comma)}}
+		}
+		lines = append(lines, textLine{depth, key + "{"})
+		keys := sortedKeys(v)
+		for i, k := range keys {
+			c := &diffNode{path: n.path.child(gojsondiff.Name(k))}
+			lines = append(lines, f.valueLines(c, v[k], depth+1, i < len(keys)-1, levels-1)...)
+		}
+		lines = append(lines, textLine{depth, "}" + commaText(comma)})
+	case []interface{}:
+		if levels == 0 && len(v) > 0 {
+			return []textLine{{depth, key + "[...]" + commaText(comma)}}
+		}
+		lines = append(lines, textLine{depth, key + "["})
+		for i, item := range v {
+			c := &diffNode{path: n.path.child(gojsondiff.Index(i))}
+			lines = append(lines, f.valueLines(c, item, depth+1, i < len(v)-1, levels-1)...)
+		}
+		lines = append(lines, textLine{depth, "]" + commaText(comma)})
+	default:
+		lines = append(lines, textLine{depth, key + f.basicText(v) + commaText(comma)})
+	}
+	return lines
+}
+
+// textDiffTexts returns the texts of a modified string represented by a
+// TextDiff, highlighting deleted text in the old value and inserted text in
+// the new value.
+func (f *textFormatter) textDiffTexts(n *diffNode) (string, string) {
+	key := f.key(n, n.left)
+	diffs := textDiffs(n.left.(string), n.right.(string), f.granularity)
+	start, end := f.highlightCodes(deletedStart, deletedEnd)
+	left := key + `"` + highlight(diffs, diffmatchpatch.DiffDelete, start, end) + `"`
+	start, end = f.highlightCodes(insertedStart, insertedEnd)
+	right := key + `"` + highlight(diffs, diffmatchpatch.DiffInsert, start, end) + `"`
+	return left, right
+}
+
+// omitted formats a line replacing a number of omitted elements of an object or array.
+func (f *textFormatter) omitted(container interface{}, count, depth int) {
+	if count > 0 {
+		f.line(markerSame, depth, omittedText(container, count))
+	}
+}
+
+// omittedText returns the text replacing a number of omitted elements of an
+// object or array (e.g. "... 42 unchanged keys ...").
+func omittedText(container interface{}, count int) string {
+	unit := "item"
+	if _, ok := container.(map[string]interface{}); ok {
+		unit = "key"
+	}
+	if count > 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("... %d unchanged %s ...", count, unit)
+}
+
+// key returns the name or index of the node with value v (e.g. `"key": ` or
+// `0: `). Like in the AsciiFormatter, basic values at the root are separated
+// from the marker by a space.
+func (f *textFormatter) key(n *diffNode, v interface{}) string {
+	switch pos := n.position().(type) {
+	case gojsondiff.Name:
+		return `"` + string(pos) + `": `
+	case gojsondiff.Index:
+		return pos.String() + ": "
+	}
+	if isContainer(v) {
+		return ""
+	}
+	return " "
+}
+
+// basicText returns the text representation of a basic value. Like in the
+// AsciiFormatter, strings are quoted, but not escaped.
+func (f *textFormatter) basicText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if max := f.opts.MaxValueLength; max > 0 && utf8.RuneCountInString(v) > max {
+			v = string([]rune(v)[:max]) + ellipsis
+		}
+		return `"` + v + `"`
+	}
+	return fmt.Sprint(v)
+}
+
+// highlightCodes returns the escape codes that enclose highlighted text if
+// coloring is enabled, or the given markers otherwise.
+func (f *textFormatter) highlightCodes(start, end string) (string, string) {
+	if !f.opts.Coloring || f.opts.Theme.Highlight == "" {
+		return start, end
+	}
+	return sgr(f.opts.Theme.Highlight), sgr(f.opts.Theme.HighlightOff)
+}
+
+// line writes a line. If the text contains line breaks, each of the following
+// lines is indented by one more level, so they're aligned with the elements
+// of objects and arrays at the same depth.
+func (f *textFormatter) line(marker string, depth int, text string) {
+	for _, l := range splitLines(depth, text) {
+		l := marker + strings.Repeat("  ", l.depth) + l.text
+		if f.opts.MaxWidth > 0 {
+			l = f.truncate(l, f.opts.MaxWidth)
+		}
+		f.buf.WriteString(f.colorize(marker, l))
+		f.buf.WriteByte('\n')
+	}
+}
+
+// splitLines splits a text at line breaks (cf. line()).
+func splitLines(depth int, text string) []textLine {
+	parts := strings.Split(text, "\n")
+	lines := make([]textLine, len(parts))
+	for i, p := range parts {
+		lines[i] = textLine{depth, p}
+		if i > 0 {
+			lines[i].depth++
+		}
+	}
+	return lines
+}
+
+// colorize colors a line according to its marker, if coloring is enabled.
+func (f *textFormatter) colorize(marker, l string) string {
+	if style := f.style(marker); style != "" {
+		return sgr(style) + l + sgr("0")
+	}
+	return l
+}
+
+// style returns the SGR parameters for lines with the given marker (if any).
+func (f *textFormatter) style(marker string) string {
+	if !f.opts.Coloring {
+		return ""
+	}
+	switch marker {
+	case markerAdded:
+		return f.opts.Theme.Added
+	case markerDeleted:
+		return f.opts.Theme.Deleted
+	}
+	return f.opts.Theme.Same
+}
+
+// sgr returns the ANSI escape sequence with the given SGR parameters.
+func sgr(params string) string {
+	return "\x1b[" + params + "m"
+}
+
+// truncate shortens a line to a display width of at most max (including the
+// ellipsis, cf. displayWidth). ANSI escape codes aren't visible, and if the
+// line is truncated within highlighted text, highlighting is disabled again.
+func (f *textFormatter) truncate(s string, max int) string {
+	on, off := f.highlightCodes("", "")
+	if displayWidth(stripANSI(s)) <= max {
+		return s
+	}
+	var buf bytes.Buffer
+	visible := 0
+	highlighted := false
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "\x1b[") {
+			end := strings.IndexByte(s[i:], 'm')
+			if end < 0 {
+				break
+			}
+			code := s[i : i+end+1]
+			highlighted = code == on
+			buf.WriteString(code)
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible+runeWidth(r) > max-1 {
+			break
+		}
+		buf.WriteRune(r)
+		visible += runeWidth(r)
+		i += size
+	}
+	buf.WriteString(ellipsis)
+	if highlighted {
+		buf.WriteString(off)
+	}
+	return buf.String()
+}
+
+// displayWidth returns the number of columns that a string (without ANSI
+// escape codes) takes up in a terminal with a monospaced font.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of columns that a character takes up in a
+// terminal: 2 for wide characters (cf. Unicode Standard Annex #11), 0 for
+// combining marks and invisible formatting characters, and 1 otherwise.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// stripANSI removes ANSI escape codes of the form "\x1b[...m".
+func stripANSI(s string) string {
+	var buf bytes.Buffer
+	for {
+		i := strings.Index(s, "\x1b[")
+		if i < 0 {
+			break
+		}
+		end := strings.IndexByte(s[i:], 'm')
+		if end < 0 {
+			break
+		}
+		buf.WriteString(s[:i])
+		s = s[i+end+1:]
+	}
+	buf.WriteString(s)
+	return buf.String()
+}
+
+// openText returns the first line of an object or array without the key.
+func openText(v interface{}) string {
+	if _, ok := v.([]interface{}); ok {
+		return "["
+	}
+	return "{"
+}
+
+// commaText returns the comma separating an element from the next element.
+func commaText(comma bool) string {
+	if comma {
+		return ","
+	}
+	return ""
+}
+
+// hasLeftSibling returns true if any of the nodes has a value on the left
+// side. Like the AsciiFormatter, which formats the left value and inserts the
+// changes, we only separate elements of the left value by commas.
+func hasLeftSibling(ns []*diffNode) bool {
+	for _, n := range ns {
+		if n.kind != nodeAdded {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleJSONDiff_FormatWithOptions() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.Compare(
+		[]byte(`{"a": 1, "b": 2, "c": {"d": [1, 2, 3], "e": "foo"}, "f": {"g": 4}, "h": 5}`),
+		[]byte(`{"a": 1, "b": 2, "c": {"d": [1, 2, 3], "e": "bar"}, "f": {"g": 4}, "h": 5}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	diff, err := d.FormatWithOptions(FormatOptions{ChangesOnly: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(diff)
+	// Output:
+	//  {
+	//    ... 2 unchanged keys ...
+	//    "c": {
+	//      ... 1 unchanged key ...
+	// -    "e": "foo"
+	// +    "e": "bar"
+	//    },
+	//    ... 2 unchanged keys ...
+	//  }
+}
+
+func TestJSONDiff_Format_coloring(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		opts     FormatOptions
+		expected string
+	}
+	tcs := []testCase{
+		{
+			`1`,
+			`true`,
+			FormatOptions{Coloring: true},
+			"\x1b[30;41m- 1\x1b[0m\n\x1b[30;42m+ true\x1b[0m\n",
+		},
+		{
+			`{"a": [1, 2]}`,
+			`{"a": [1, 3], "b": "x"}`,
+			FormatOptions{Coloring: true},
+			" {\n   \"a\": [\n     0: 1,\n\x1b[30;41m-    1: 2\x1b[0m\n\x1b[30;42m+    1: 3\x1b[0m\n   ]\n\x1b[30;42m+  \"b\": \"x\"\x1b[0m\n }\n",
+		},
+		{
+			`{"a": 1, "b": "foo bar"}`,
+			`{"a": 1, "b": "foo baz"}`,
+			FormatOptions{Coloring: true, Theme: &Theme256},
+			"\x1b[38;5;244m {\x1b[0m\n\x1b[38;5;244m   \"a\": 1,\x1b[0m\n" +
+				"\x1b[38;5;88;48;5;224m-  \"b\": \"foo ba\x1b[1;4mr\x1b[22;24m\"\x1b[0m\n" +
+				"\x1b[38;5;22;48;5;194m+  \"b\": \"foo ba\x1b[1;4mz\x1b[22;24m\"\x1b[0m\n" +
+				"\x1b[38;5;244m }\x1b[0m\n",
+		},
+		{
+			`"foo bar"`,
+			`"foo baz"`,
+			FormatOptions{Coloring: true, Theme: &Theme{Added: "32"}},
+			"- \"foo ba[-r-]\"\n\x1b[32m+ \"foo ba{+z+}\"\x1b[0m\n",
+		},
+		{
+			`"foo bar"`,
+			`"foo baz"`,
+			FormatOptions{Coloring: true, MaxWidth: 10},
+			"\x1b[30;41m- \"foo ba\x1b[7m…\x1b[27m\x1b[0m\n\x1b[30;42m+ \"foo ba\x1b[7m…\x1b[27m\x1b[0m\n",
+		},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, TextDiffMinimumLength: 1}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := d.FormatWithOptions(tc.opts)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %q; got %q", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestJSONDiff_FormatWithOptions_default(t *testing.T) {
+	// with the default options, the output is the same as that of Format
+	type testCase struct {
+		a string
+		b string
+	}
+	tcs := []testCase{
+		{`"hi"`, `"hello"`},
+		{`1`, `true`},
+		{`[1, 0.2]`, `[1.04, 0.13]`},
+		{`{"a": false, "b": [1, {"c": 0.2, "d": "foo"}]}`, `{"a": false, "b": [1.04, {"c": 0.13, "d": "foo"}]}`},
+		{`{"x": 1.6, "y": [3.8, "hello"]}`, `{"x": 1.57, "y": [3.6, "hello"], "z": 0}`},
+		{`[1, 2, 3]`, `[1]`},
+		{`{"a": "a long string"}`, `{"a": "a lung string"}`},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, TextDiffMinimumLength: 10}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, coloring := range []bool{false, true} {
+			expected, err := d.Format(coloring)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := d.FormatWithOptions(FormatOptions{Coloring: coloring})
+			if err != nil {
+				t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+			} else if actual != expected {
+				t.Errorf("[%v == %v, %v] expected %q; got %q", tc.a, tc.b, coloring, expected, actual)
+			}
+		}
+	}
+}
+
+func TestJSONDiff_Format_noColor(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	d, err := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}.Compare([]byte(`1`), []byte(`2`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, noColor := range []string{"", "1"} {
+		os.Setenv("NO_COLOR", noColor)
+		actual, err := d.Format(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if colored := strings.Contains(actual, "\x1b["); colored != (noColor == "") {
+			t.Errorf("[NO_COLOR=%v] expected coloring %v; got %q", noColor, noColor == "", actual)
+		}
+	}
+}
+
+func TestJSONDiff_FormatWithOptions(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		opts     FormatOptions
+		expected string
+	}
+	tcs := []testCase{
+		{
+			`{"a": 1, "b": {"c": {"d": 2}}, "e": 3}`,
+			`{"a": 1, "b": {"c": {"d": 2}}, "e": 4}`,
+			FormatOptions{ChangesOnly: true},
+			" {\n   ... 2 unchanged keys ...\n-  \"e\": 3\n+  \"e\": 4\n }\n",
+		},
+		{
+			`{"a": 1, "b": {"c": {"d": 2}}, "e": 3}`,
+			`{"a": 1, "b": {"c": {"d": 2}}, "e": 4}`,
+			FormatOptions{ChangesOnly: true, Context: 2},
+			" {\n   \"a\": 1,\n   \"b\": {\n     \"c\": {...}\n   },\n-  \"e\": 3\n+  \"e\": 4\n }\n",
+		},
+		{
+			`[1, 2, 3, {"a": [1, 2]}, 5]`,
+			`[1, 2, 3, {"a": [1, 3]}, 5]`,
+			FormatOptions{ChangesOnly: true},
+			" [\n   ... 3 unchanged items ...\n   3: {\n     \"a\": [\n       ... 1 unchanged item ...\n-      1: 2\n+      1: 3\n     ]\n   },\n   ... 1 unchanged item ...\n ]\n",
+		},
+		{
+			`{"a": [1, 2]}`,
+			`{"a": [1, 2]}`,
+			FormatOptions{ChangesOnly: true},
+			" {...}\n",
+		},
+		{
+			`{"a": [1, 2]}`,
+			`{"a": [1, 2]}`,
+			FormatOptions{ChangesOnly: true, Context: 1},
+			" {\n   \"a\": [...]\n }\n",
+		},
+		{
+			`{"a": "short", "b": "a rather long value"}`,
+			`{"a": "shorter", "b": "a rather long value"}`,
+			FormatOptions{MaxValueLength: 6},
+			" {\n-  \"a\": \"short\",\n+  \"a\": \"shorte…\",\n   \"b\": \"a rath…\"\n }\n",
+		},
+		{
+			`{"key": "value", "other": 1}`,
+			`{"key": "value", "other": 2}`,
+			FormatOptions{MaxWidth: 12},
+			" {\n   \"key\": \"…\n-  \"other\":…\n+  \"other\":…\n }\n",
+		},
+		{
+			`{"a": {"b": 1}, "c": [1]}`,
+			`{"a": [2], "c": [1, {"d": null}]}`,
+			FormatOptions{},
+			" {\n-  \"a\": {\n-    \"b\": 1\n-  },\n+  \"a\": [\n+    0: 2\n+  ],\n   \"c\": [\n     0: 1\n+    1: {\n+      \"d\": null\n+    }\n   ]\n }\n",
+		},
+		{
+			`{"$": {"a": "\"$\": 1\n"}}`,
+			`{"$": {"a": "\"$\": 2\n"}}`,
+			FormatOptions{},
+			" {\n   \"$\": {\n-    \"a\": \"\"$\": 1\n-      \"\n+    \"a\": \"\"$\": 2\n+      \"\n   }\n }\n",
+		},
+		{
+			`{"a": {"b": "line 1\nline 2"}}`,
+			`{"a": {"b": "line 1\nline 3"}}`,
+			FormatOptions{},
+			" {\n   \"a\": {\n-    \"b\": \"line 1\n-      line 2\"\n+    \"b\": \"line 1\n+      line 3\"\n   }\n }\n",
+		},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := d.FormatWithOptions(tc.opts)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %q; got %q", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestTextFormatter_truncate(t *testing.T) {
+	type testCase struct {
+		s        string
+		max      int
+		expected string
+	}
+	on, off := sgr(DefaultTheme.Highlight), sgr(DefaultTheme.HighlightOff)
+	tcs := []testCase{
+		{"short", 5, "short"},
+		{"longer", 5, "long…"},
+		{"äöüäöü", 4, "äöü…"},
+		{"日本語", 6, "日本語"},
+		{"日本語", 5, "日本…"},
+		{"日本語", 4, "日…"},
+		{"a日本", 4, "a日…"},
+		{"e\u0301e\u0301e\u0301", 3, "e\u0301e\u0301e\u0301"},
+		{"\x1b[30;42m+abc\x1b[0m", 4, "\x1b[30;42m+abc\x1b[0m"},
+		{"ab" + on + "cdef" + off + "g", 4, "ab" + on + "c…" + off},
+		{"ab" + on + "c" + off + "defg", 5, "ab" + on + "c" + off + "d…"},
+	}
+	f := textFormatter{opts: FormatOptions{Coloring: true, Theme: &DefaultTheme}}
+	for _, tc := range tcs {
+		if actual := f.truncate(tc.s, tc.max); actual != tc.expected {
+			t.Errorf("[%q, %v] expected %q; got %q", tc.s, tc.max, tc.expected, actual)
+		}
+	}
+}
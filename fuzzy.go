@@ -0,0 +1,174 @@
+package compare
+
+import (
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// StringEqualer provides a function for determining if two strings are equal.
+type StringEqualer interface {
+	// Equal determines if two strings are equal.
+	Equal(string, string) bool
+}
+
+// EditDistance returns the number of edits needed to transform one string into
+// another.
+type EditDistance func(string, string) int
+
+// FuzzyStringEqualer is a StringEqualer that considers two strings equal if
+// the edit distance between them is within a threshold.
+//
+// The threshold can be specified as an absolute number of edits, as a fraction
+// of the length of the longer string (measured in runes), or both, in which
+// case the stricter threshold applies. For example, if MaxDistance is 3 and
+// MaxDistanceRatio is 0.1, then strings of 20 runes may differ by up to 2 edits,
+// and strings of 50 runes may differ by up to 3 edits. Note that a maximum
+// distance ratio of r is equivalent to a minimum similarity of 1-r.
+//
+// If no threshold is specified, strings are compared exactly.
+type FuzzyStringEqualer struct {
+	// Distance specifies how the edit distance is calculated (Levenshtein by default).
+	Distance EditDistance
+	// MaxDistance specifies the maximum number of edits.
+	MaxDistance int
+	// MaxDistanceRatio specifies the maximum number of edits as a fraction of
+	// the length of the longer string.
+	MaxDistanceRatio float64
+}
+
+// Equal determines if the edit distance between two strings is within the threshold.
+func (fe FuzzyStringEqualer) Equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	limit := fe.maxDistance(a, b)
+	if limit <= 0 {
+		return false
+	}
+
+	// the edit distance is at least the difference in length
+	la, lb := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	if la-lb > limit || lb-la > limit {
+		return false
+	}
+
+	distance := fe.Distance
+	if distance == nil {
+		distance = Levenshtein
+	}
+	return distance(a, b) <= limit
+}
+
+// maxDistance returns the maximum number of edits for two strings.
+func (fe FuzzyStringEqualer) maxDistance(a, b string) int {
+	limit := fe.MaxDistance
+	if fe.MaxDistanceRatio > 0 {
+		length := utf8.RuneCountInString(a)
+		if lb := utf8.RuneCountInString(b); lb > length {
+			length = lb
+		}
+		if r := int(fe.MaxDistanceRatio * float64(length)); fe.MaxDistance <= 0 || r < limit {
+			limit = r
+		}
+	}
+	return limit
+}
+
+// Levenshtein returns the minimum number of single-rune insertions, deletions
+// and substitutions needed to transform one string into another.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// only keep the previous and the current row of the distance matrix
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// DamerauLevenshtein returns the minimum number of single-rune insertions,
+// deletions and substitutions and transpositions of adjacent runes needed to
+// transform one string into another, with the restriction that no substring is
+// edited more than once (also known as the optimal string alignment distance).
+func DamerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// only keep the last three rows of the distance matrix
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && prev2[j-2]+1 < curr[j] {
+				curr[j] = prev2[j-2] + 1
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// DiffLevenshtein returns the Levenshtein distance derived from a diff of two
+// strings computed with diffmatchpatch. It's typically much faster than
+// Levenshtein for long strings, but since the diff isn't necessarily minimal,
+// the result may overestimate the actual distance.
+//
+// cf. diffmatchpatch.DiffLevenshtein(), which counts bytes rather than runes
+func DiffLevenshtein(a, b string) int {
+	dmp := diffmatchpatch.New()
+	distance, insertions, deletions := 0, 0, 0
+	for _, d := range dmp.DiffMain(a, b, false) {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			insertions += utf8.RuneCountInString(d.Text)
+		case diffmatchpatch.DiffDelete:
+			deletions += utf8.RuneCountInString(d.Text)
+		case diffmatchpatch.DiffEqual:
+			// a deletion and an insertion is one substitution
+			distance += max2(insertions, deletions)
+			insertions, deletions = 0, 0
+		}
+	}
+	return distance + max2(insertions, deletions)
+}
+
+func max2(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,159 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleFuzzyStringEqualer() {
+	e := TolerantBasicEqualer{
+		StringEqualer: FuzzyStringEqualer{MaxDistance: 2},
+	}
+	fmt.Println(e.String("A tabby cat on a mat", "A tabby cat on the mat"))
+	fmt.Println(e.String("A tabby cat on a mat", "A tabby cat on a hat"))
+	// Output:
+	// false
+	// true
+}
+
+func TestEditDistances(t *testing.T) {
+	type testCase struct {
+		a           string
+		b           string
+		levenshtein int
+		damerau     int
+	}
+	tcs := []testCase{
+		{"", "", 0, 0},
+		{"", "abc", 3, 3},
+		{"abc", "", 3, 3},
+		{"abc", "abc", 0, 0},
+		{"kitten", "sitting", 3, 3},
+		{"sitting", "kitten", 3, 3},
+		{"flaw", "lawn", 2, 2},
+		{"ab", "ba", 2, 1},
+		{"abcd", "acbd", 2, 1},
+		{"ca", "abc", 3, 3},
+		{"Größe", "Grösse", 2, 2},
+		{"日本語", "日本", 1, 1},
+	}
+	for _, tc := range tcs {
+		if actual := Levenshtein(tc.a, tc.b); actual != tc.levenshtein {
+			t.Errorf("[Levenshtein(%q, %q)] expected %v; got %v", tc.a, tc.b, tc.levenshtein, actual)
+		}
+		if actual := DamerauLevenshtein(tc.a, tc.b); actual != tc.damerau {
+			t.Errorf("[DamerauLevenshtein(%q, %q)] expected %v; got %v", tc.a, tc.b, tc.damerau, actual)
+		}
+		// the diff-based distance may overestimate, but never underestimate the distance
+		if actual := DiffLevenshtein(tc.a, tc.b); actual < tc.levenshtein {
+			t.Errorf("[DiffLevenshtein(%q, %q)] expected at least %v; got %v", tc.a, tc.b, tc.levenshtein, actual)
+		}
+	}
+
+	long1 := strings.Repeat("the quick brown fox jumps over the lazy dog ", 50)
+	long2 := strings.Replace(long1, "lazy", "hazy", 3)
+	if actual := DiffLevenshtein(long1, long2); actual != 3 {
+		t.Errorf("[DiffLevenshtein] expected 3; got %v", actual)
+	}
+}
+
+func TestFuzzyStringEqualer_Equal(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		expected bool
+	}
+
+	exact := []testCase{
+		{"", "", true},
+		{"foo", "foo", true},
+		{"foo", "fo", false},
+	}
+
+	absolute := []testCase{
+		{"kitten", "sitten", true},
+		{"kitten", "sittin", true},
+		{"kitten", "sitting", false},
+		{"a", "abc", true},
+		{"a", "abcd", false},
+		{"ab", "ba", true},
+	}
+
+	relative := []testCase{
+		{"kitten", "sitten", false},         // 1 edit for 6 runes is too many
+		{"0123456789", "012345678x", true},  // 1 edit for 10 runes
+		{"0123456789", "01234567xx", false}, // 2 edits for 10 runes
+		{"01234567890123456789", "x1234567890123456789", true},
+		{"01234567890123456789", "xx234567890123456789", true},
+		{"01234567890123456789", "xxx34567890123456789", false},
+	}
+
+	stricter := []testCase{
+		{"0123456789", "012345678x", true},
+		{"0123456789", "01234567xx", false}, // the relative threshold is stricter
+		{"012345678901234567890123456789", "xx2345678901234567890123456789", true},
+		{"012345678901234567890123456789", "xxx345678901234567890123456789", false}, // the absolute threshold is stricter
+	}
+
+	transpositions := []testCase{
+		{"abcd", "bacd", true},
+		{"abcd", "badc", false},
+	}
+
+	e := FuzzyStringEqualer{}
+	for _, tc := range exact {
+		if actual := e.Equal(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	e = FuzzyStringEqualer{MaxDistance: 2}
+	for _, tc := range absolute {
+		if actual := e.Equal(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	e = FuzzyStringEqualer{MaxDistanceRatio: 0.1}
+	for _, tc := range relative {
+		if actual := e.Equal(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	e = FuzzyStringEqualer{MaxDistance: 2, MaxDistanceRatio: 0.1}
+	for _, tc := range stricter {
+		if actual := e.Equal(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+
+	e = FuzzyStringEqualer{Distance: DamerauLevenshtein, MaxDistance: 1}
+	for _, tc := range transpositions {
+		if actual := e.Equal(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestTolerantBasicEqualer_String_fuzzy(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		expected bool
+	}
+	tcs := []testCase{
+		{"Hello World", "hello  world!", true},
+		{"Hello World", "Goodbye World", false},
+	}
+	e := TolerantBasicEqualer{
+		StringTransformer: Chain{CaseFolder{}, SpaceCollapser{}},
+		StringEqualer:     FuzzyStringEqualer{MaxDistance: 1},
+	}
+	for _, tc := range tcs {
+		if actual := e.String(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/yudai/gojsondiff"
+)
+
+// HTMLOptions specifies how differences are rendered as HTML.
+type HTMLOptions struct {
+	// Title specifies the title of the report ("JSON Diff" by default).
+	Title string
+	// SideBySide specifies whether the left and the right value are rendered
+	// in separate columns. By default, they're rendered in a single column,
+	// with deleted values preceding the values that replace them.
+	SideBySide bool
+	// ExpandUnchanged specifies whether unchanged objects and arrays are
+	// initially expanded. Either way, they can be collapsed and expanded.
+	ExpandUnchanged bool
+}
+
+// WriteHTML writes a self-contained HTML document describing the differences
+// between two JSON values. The document consists of a summary of the
+// differences followed by the values, where added, deleted and modified
+// values are highlighted. Values that aren't identical, but were considered
+// equal, are also highlighted, and their tooltips show which rule made them
+// equal. The document doesn't reference any external resources.
+func (d *JSONDiff) WriteHTML(w io.Writer, opts HTMLOptions) error {
+	if opts.Title == "" {
+		opts.Title = "JSON Diff"
+	}
+
+	r := htmlRenderer{opts: opts, granularity: d.granularity}
+	r.node(newDiffTree(d), 0)
+
+	counts := countDeltas(d.ds)
+	return htmlTemplate.Execute(w, map[string]interface{}{
+		"Title":      opts.Title,
+		"SideBySide": opts.SideBySide,
+		"Added":      counts.added,
+		"Deleted":    counts.deleted,
+		"Modified":   counts.modified,
+		"Tolerated":  len(d.tolerances),
+		"Body":       template.HTML(r.buf.String()), // nolint: gas
+	})
+}
+
+// htmlRenderer renders a diff tree as HTML.
+type htmlRenderer struct {
+	opts        HTMLOptions
+	granularity Granularity
+	buf         bytes.Buffer
+}
+
+func (r *htmlRenderer) node(n *diffNode, depth int) {
+	switch n.kind {
+	case nodeSame:
+		if len(n.children) == 0 {
+			r.leaf(n, depth)
+			return
+		}
+		open := ""
+		if depth == 0 || r.opts.ExpandUnchanged {
+			open = " open"
+		}
+		fmt.Fprintf(&r.buf, `<details class="same"%s><summary>`, open)
+		r.line(n.kind, depth, "", r.openText(n, n.left), r.openText(n, n.right), len(n.children))
+		r.buf.WriteString("</summary>")
+		r.children(n, depth)
+		r.line(n.kind, depth, "", closeText(n.left), closeText(n.right), 0)
+		r.buf.WriteString("</details>")
+	case nodeChanged:
+		r.buf.WriteString(`<details class="changed" open><summary>`)
+		r.line(n.kind, depth, "", r.openText(n, n.left), r.openText(n, n.right), 0)
+		r.buf.WriteString("</summary>")
+		r.children(n, depth)
+		r.line(n.kind, depth, "", closeText(n.left), closeText(n.right), 0)
+		r.buf.WriteString("</details>")
+	case nodeAdded, nodeDeleted:
+		if len(n.children) == 0 {
+			r.leaf(n, depth)
+			return
+		}
+		r.sideLine(n, depth, r.openText(n, n.value()))
+		r.children(n, depth)
+		r.sideLine(n, depth, closeText(n.value()))
+	case nodeModified:
+		if !isContainer(n.left) && !isContainer(n.right) {
+			l, rt := r.modifiedTexts(n)
+			if r.opts.SideBySide {
+				r.line(nodeModified, depth, "", l, rt, 0)
+			} else {
+				r.rawLine("deleted", depth, "", l, "", 0)
+				r.rawLine("added", depth, "", "", rt, 0)
+			}
+			return
+		}
+		r.node(valueTree(nodeDeleted, n.path, n.left), depth)
+		r.node(valueTree(nodeAdded, n.path, n.right), depth)
+	}
+}
+
+func (r *htmlRenderer) children(n *diffNode, depth int) {
+	for _, c := range n.children {
+		r.node(c, depth+1)
+	}
+}
+
+// leaf renders a node without children.
+func (r *htmlRenderer) leaf(n *diffNode, depth int) {
+	switch n.kind {
+	case nodeSame:
+		title := ""
+		kind := "same"
+		if t := n.tolerance; t != nil {
+			kind = "tolerated"
+			title = fmt.Sprintf("%s\nleft: %s\nright: %s", t.Rule, jsonText(t.Left), jsonText(t.Right))
+		}
+		r.rawLine(kind, depth, title,
+			template.HTMLEscapeString(r.keyText(n)+jsonText(n.left)),
+			template.HTMLEscapeString(r.keyText(n)+jsonText(n.right)), 0)
+	default:
+		r.sideLine(n, depth, template.HTMLEscapeString(r.keyText(n)+jsonText(n.value())))
+	}
+}
+
+// sideLine renders a line of an added or deleted node, which only has content on one side.
+func (r *htmlRenderer) sideLine(n *diffNode, depth int, text string) {
+	if n.kind == nodeAdded {
+		r.rawLine("added", depth, "", "", text, 0)
+	} else {
+		r.rawLine("deleted", depth, "", text, "", 0)
+	}
+}
+
+// line renders a line with the same kind on both sides. The texts must already be escaped.
+func (r *htmlRenderer) line(kind nodeKind, depth int, title, left, right string, collapsed int) {
+	names := map[nodeKind]string{nodeSame: "same", nodeChanged: "same", nodeModified: "modified"}
+	r.rawLine(names[kind], depth, title, left, right, collapsed)
+}
+
+// rawLine renders a single line. If collapsed is positive, the line is the
+// summary of a collapsible object or array with that many elements.
+func (r *htmlRenderer) rawLine(kind string, depth int, title, left, right string, collapsed int) {
+	fmt.Fprintf(&r.buf, `<div class="line %s" style="--depth:%d"`, kind, depth)
+	if title != "" {
+		fmt.Fprintf(&r.buf, ` title="%s"`, template.HTMLEscapeString(title))
+	}
+	r.buf.WriteString(">")
+
+	suffix := ""
+	if collapsed > 0 {
+		suffix = fmt.Sprintf(`<span class="collapsed"> … %d unchanged</span>`, collapsed)
+	}
+
+	if r.opts.SideBySide {
+		if left != "" {
+			left += suffix
+		}
+		if right != "" {
+			right += suffix
+		}
+		fmt.Fprintf(&r.buf, `<span class="l">%s</span><span class="r">%s</span>`, left, right)
+	} else {
+		markers := map[string]string{"added": "+", "deleted": "-"}
+		text := left
+		if kind == "added" {
+			text = right
+		}
+		fmt.Fprintf(&r.buf, `<span class="marker">%s</span><span class="text">%s%s</span>`,
+			markers[kind], text, suffix)
+	}
+	r.buf.WriteString("</div>\n")
+}
+
+// openText returns the escaped text of the first line of a node (e.g. `"key": {`).
+func (r *htmlRenderer) openText(n *diffNode, v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return template.HTMLEscapeString(r.keyText(n) + "{")
+	case []interface{}:
+		return template.HTMLEscapeString(r.keyText(n) + "[")
+	}
+	return ""
+}
+
+// closeText returns the text of the last line of an object or array.
+func closeText(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "}"
+	case []interface{}:
+		return "]"
+	}
+	return ""
+}
+
+// keyText returns the name or index of the node (e.g. `"key": ` or `0: `).
+func (r *htmlRenderer) keyText(n *diffNode) string {
+	switch pos := n.position().(type) {
+	case gojsondiff.Name:
+		return jsonText(string(pos)) + ": "
+	case gojsondiff.Index:
+		return pos.String() + ": "
+	}
+	return ""
+}
+
+// modifiedTexts returns the escaped texts of a modified leaf. For text diffs,
+// deleted and inserted text is highlighted.
+func (r *htmlRenderer) modifiedTexts(n *diffNode) (string, string) {
+	key := template.HTMLEscapeString(r.keyText(n))
+	if !n.textDiff {
+		return key + template.HTMLEscapeString(jsonText(n.left)),
+			key + template.HTMLEscapeString(jsonText(n.right))
+	}
+	diffs := textDiffs(n.left.(string), n.right.(string), r.granularity)
+	return key + htmlHighlight(diffs, diffmatchpatch.DiffDelete, "del"),
+		key + htmlHighlight(diffs, diffmatchpatch.DiffInsert, "ins")
+}
+
+// htmlHighlight renders a quoted string consisting of all unchanged diffs and
+// all diffs of the given type, wrapping the latter in the given element.
+// cf. highlight()
+func htmlHighlight(diffs []diffmatchpatch.Diff, op diffmatchpatch.Operation, elem string) string {
+	var buf bytes.Buffer
+	buf.WriteString("&#34;")
+	for _, d := range diffs {
+		text := jsonText(d.Text)
+		text = template.HTMLEscapeString(text[1 : len(text)-1]) // strip quotes
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			buf.WriteString(text)
+		case op:
+			fmt.Fprintf(&buf, "<%s>%s</%s>", elem, text, elem)
+		}
+	}
+	buf.WriteString("&#34;")
+	return buf.String()
+}
+
+// jsonText returns the JSON representation of a basic value. Unlike
+// json.Marshal(), it doesn't escape HTML characters, which would be confusing
+// when displaying the values (and which HTML templates take care of anyway).
+func jsonText(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// deltaCounts holds the number of deltas of each type.
+type deltaCounts struct {
+	added, deleted, modified int
+}
+
+// countDeltas counts deltas recursively. Objects and arrays that contain
+// deltas aren't counted themselves.
+func countDeltas(ds []gojsondiff.Delta) deltaCounts {
+	var c deltaCounts
+	for _, d := range ds {
+		switch d := d.(type) {
+		case *gojsondiff.Object:
+			cc := countDeltas(d.Deltas)
+			c.added, c.deleted, c.modified = c.added+cc.added, c.deleted+cc.deleted, c.modified+cc.modified
+		case *gojsondiff.Array:
+			cc := countDeltas(d.Deltas)
+			c.added, c.deleted, c.modified = c.added+cc.added, c.deleted+cc.deleted, c.modified+cc.modified
+		case *gojsondiff.Added:
+			c.added++
+		case *gojsondiff.Deleted:
+			c.deleted++
+		default: // Modified, TextDiff
+			c.modified++
+		}
+	}
+	return c
+}
+
+var htmlTemplate = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; color: #24292e; }
+header h1 { font-size: 1.4em; margin: 0 0 0.3em; }
+.summary span { display: inline-block; margin-right: 1em; padding: 0.1em 0.5em; border-radius: 3px; }
+.summary .added { background: #e6ffed; }
+.summary .deleted { background: #ffeef0; }
+.summary .modified { background: #fff5b1; }
+.summary .tolerated { background: #f1f8ff; }
+.diff { font-family: monospace; border: 1px solid #e1e4e8; margin-top: 1em; }
+.line { white-space: pre; }
+.line .marker { display: inline-block; width: 2ch; }
+.line .text, .sbs .line > span { padding-left: calc(var(--depth) * 2ch); }
+.sbs .line { display: grid; grid-template-columns: 1fr 1fr; }
+.sbs .line > span { overflow: hidden; text-overflow: ellipsis; }
+.sbs .line > .l { border-right: 1px solid #e1e4e8; }
+.line.added, .sbs .line.added > .r, .sbs .line.modified > .r { background: #e6ffed; }
+.line.deleted, .sbs .line.deleted > .l, .sbs .line.modified > .l { background: #ffeef0; }
+.sbs .line.added, .sbs .line.deleted { background: none; }
+.line.tolerated { background: #f1f8ff; text-decoration: underline dotted; cursor: help; }
+ins { background: #acf2bd; text-decoration: none; }
+del { background: #fdb8c0; text-decoration: none; }
+summary { display: block; cursor: pointer; list-style: none; }
+summary::-webkit-details-marker { display: none; }
+details[open] > summary .collapsed { display: none; }
+.collapsed { color: #6a737d; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p class="summary"><span class="added">{{.Added}} added</span><span class="deleted">{{.Deleted}} deleted</span><span class="modified">{{.Modified}} modified</span><span class="tolerated">{{.Tolerated}} tolerated</span></p>
+</header>
+<div class="diff{{if .SideBySide}} sbs{{end}}">
+{{.Body}}</div>
+</body>
+</html>
+`))
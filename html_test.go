@@ -0,0 +1,106 @@
+package compare
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONDiff_WriteHTML(t *testing.T) {
+	jd := JSONDiffer{
+		BasicEqualer: TolerantBasicEqualer{
+			Float64Tolerance: 0.1,
+			TimeLayout:       time.RFC3339,
+			TimeTolerance:    time.Second,
+		},
+		TextDiffMinimumLength: 10,
+	}
+	d, err := jd.Compare(
+		[]byte(`{"a": 1.6, "b": [1, 2, 3], "c": {"d": "<script>", "e": "a long string"}, "f": "2018-03-30T14:36:09Z", "g": {"h": 1}}`),
+		[]byte(`{"a": 1.57, "b": [1, 2], "c": {"d": "<script>", "e": "a lung string", "x": true}, "f": "2018-03-30T14:36:10Z", "g": {"h": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		opts     HTMLOptions
+		expected []string
+	}
+	tcs := []testCase{
+		{
+			HTMLOptions{},
+			[]string{
+				`<title>JSON Diff</title>`,
+				`<span class="added">1 added</span>`,
+				`<span class="deleted">1 deleted</span>`,
+				`<span class="modified">1 modified</span>`,
+				`<span class="tolerated">2 tolerated</span>`,
+				"<div class=\"line tolerated\" style=\"--depth:1\" title=\"Float64Tolerance: 0.1\nleft: 1.6\nright: 1.57\">",
+				"title=\"TimeTolerance: 1s\nleft: &#34;2018-03-30T14:36:09Z&#34;\nright: &#34;2018-03-30T14:36:10Z&#34;\"",
+				`<span class="marker">-</span><span class="text">2: 3</span>`,
+				`<span class="marker">+</span><span class="text">&#34;x&#34;: true</span>`,
+				`&#34;d&#34;: &#34;&lt;script&gt;&#34;`,
+				`&#34;e&#34;: &#34;a l<del>o</del>ng string&#34;`,
+				`&#34;e&#34;: &#34;a l<ins>u</ins>ng string&#34;`,
+				`<details class="same"><summary>`,
+				`<span class="collapsed"> … 1 unchanged</span>`,
+			},
+		},
+		{
+			HTMLOptions{Title: "Fixtures", SideBySide: true, ExpandUnchanged: true},
+			[]string{
+				`<title>Fixtures</title>`,
+				`<div class="diff sbs">`,
+				`<div class="line deleted" style="--depth:2"><span class="l">2: 3</span><span class="r"></span></div>`,
+				`<div class="line added" style="--depth:2"><span class="l"></span><span class="r">&#34;x&#34;: true</span></div>`,
+				`<span class="l">&#34;e&#34;: &#34;a l<del>o</del>ng string&#34;</span><span class="r">&#34;e&#34;: &#34;a l<ins>u</ins>ng string&#34;</span>`,
+				`<span class="l">&#34;a&#34;: 1.6</span><span class="r">&#34;a&#34;: 1.57</span>`,
+				`<details class="same" open><summary>`,
+			},
+		},
+	}
+	for _, tc := range tcs {
+		var buf bytes.Buffer
+		if err := d.WriteHTML(&buf, tc.opts); err != nil {
+			t.Errorf("[%+v] %v", tc.opts, err)
+			continue
+		}
+		html := buf.String()
+		for _, expected := range tc.expected {
+			if !strings.Contains(html, expected) {
+				t.Errorf("[%+v] expected HTML to contain %q; got %v", tc.opts, expected, html)
+			}
+		}
+		if strings.Contains(html, "<script>") {
+			t.Errorf("[%+v] expected HTML to be escaped; got %v", tc.opts, html)
+		}
+		if external := regexp.MustCompile(`(src|href)=`); external.MatchString(html) {
+			t.Errorf("[%+v] expected no external assets; got %v", tc.opts, html)
+		}
+	}
+}
+
+func TestJSONDiff_WriteHTML_modifiedTypes(t *testing.T) {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.Compare([]byte(`{"a": [1]}`), []byte(`{"a": {"b": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := d.WriteHTML(&buf, HTMLOptions{SideBySide: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`<div class="line deleted" style="--depth:1"><span class="l">&#34;a&#34;: [</span><span class="r"></span></div>`,
+		`<div class="line deleted" style="--depth:2"><span class="l">0: 1</span><span class="r"></span></div>`,
+		`<div class="line added" style="--depth:1"><span class="l"></span><span class="r">&#34;a&#34;: {</span></div>`,
+		`<div class="line added" style="--depth:2"><span class="l"></span><span class="r">&#34;b&#34;: 1</span></div>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("expected HTML to contain %q; got %v", e, buf.String())
+		}
+	}
+}
@@ -1,19 +1,30 @@
 package compare
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"reflect"
-	"regexp"
 	"sort"
+	"sync"
+	"sync/atomic"
 
+	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/yudai/gojsondiff"
-	"github.com/yudai/gojsondiff/formatter"
 )
 
 // JSONDiff represents the differences between two JSON values.
 type JSONDiff struct {
-	left map[string]interface{}
-	ds   []gojsondiff.Delta
+	left        map[string]interface{}
+	right       interface{}
+	ds          []gojsondiff.Delta
+	tolerances  []Tolerance
+	granularity Granularity
+	subset      bool
+	matching    ArrayMatching
+
+	statsOnce sync.Once
+	stats     Stats
 }
 
 // Deltas returns Deltas that describe individual differences between two JSON values.
@@ -21,142 +32,540 @@ func (d *JSONDiff) Deltas() []gojsondiff.Delta {
 	return d.ds
 }
 
-// Modified returns true if JSONDiff has at least one Delta.
-func (d *JSONDiff) Modified() bool {
-	return len(d.ds) > 0
+// Tolerances returns descriptions of basic values that aren't identical, but
+// were considered equal by the BasicEqualer (e.g. because of the StringTransformer
+// of a TolerantBasicEqualer).
+func (d *JSONDiff) Tolerances() []Tolerance {
+	return d.tolerances
 }
 
-// Format returns a string representation of the differences between two JSON values.
-func (d *JSONDiff) Format(coloring bool) (string, error) {
-	config := formatter.AsciiFormatterConfig{
-		ShowArrayIndex: true,
-		Coloring:       coloring,
+// Differences returns the individual differences between two JSON values.
+// Unlike Deltas, which are nested like the JSON values, the differences are
+// flat, and each of them contains the full path of the value that differs.
+func (d *JSONDiff) Differences() []Difference {
+	var diffs []Difference
+	for _, delta := range d.ds {
+		// the explicit root isn't part of the path
+		diffs = appendDifferences(diffs, nil, delta)
 	}
-	af := formatter.NewAsciiFormatter(d.left, config)
-	diff, err := af.Format(d)
-	if err != nil {
-		return "", err
+	return diffs
+}
+
+// appendDifferences appends the differences described by a Delta to diffs.
+// cf. https://github.com/yudai/gojsondiff/blob/master/formatter/delta.go#L58-L104
+func appendDifferences(diffs []Difference, path Path, delta gojsondiff.Delta) []Difference {
+	switch d := delta.(type) {
+	case *gojsondiff.Object:
+		for _, child := range d.Deltas {
+			diffs = appendDifferences(diffs, path.child(deltaPosition(child)), child)
+		}
+	case *gojsondiff.Array:
+		for _, child := range d.Deltas {
+			diffs = appendDifferences(diffs, path.child(deltaPosition(child)), child)
+		}
+	case *gojsondiff.Added:
+		diffs = append(diffs, Difference{Path: path, Operation: OperationAdd, Right: d.Value})
+	case *gojsondiff.Deleted:
+		diffs = append(diffs, Difference{Path: path, Operation: OperationRemove, Left: d.Value})
+	case *gojsondiff.TextDiff:
+		diffs = append(diffs, replaced(path, d.OldValue, d.NewValue))
+	case *gojsondiff.Modified:
+		diffs = append(diffs, replaced(path, d.OldValue, d.NewValue))
+	}
+	return diffs
+}
+
+// replaced returns the Difference for a basic value that was replaced by another value.
+func replaced(path Path, left, right interface{}) Difference {
+	comparator := "type"
+	if reflect.TypeOf(left) == reflect.TypeOf(right) {
+		switch left.(type) {
+		case bool:
+			comparator = "Bool"
+		case float64:
+			comparator = "Float64"
+		case string:
+			comparator = "String"
+		}
 	}
+	return Difference{Path: path, Operation: OperationReplace, Left: left, Right: right, Comparator: comparator}
+}
 
-	// remove wrapping object for values of basic types
-	basicRE := regexp.MustCompile(`^\s*{\n-\s*"\$":\s*([\s\S]*)\+\s*"\$":\s*([\s\S]*)\n\s*}`)
-	unwrapped := basicRE.ReplaceAllString(diff, "- $1+ $2")
+// deltaPosition returns the (post-)position of a Delta.
+func deltaPosition(d gojsondiff.Delta) gojsondiff.Position {
+	switch pd := d.(type) {
+	case gojsondiff.PostDelta:
+		return pd.PostPosition()
+	case gojsondiff.PreDelta:
+		return pd.PrePosition()
+	}
+	return nil
+}
 
-	// remove wrapping object for arrays and objects
-	nonBasicRE := regexp.MustCompile(`^\s*{\n\s*"\$":([\s\S]*)\n\s}`)
-	unwrapped = nonBasicRE.ReplaceAllString(unwrapped, "$1")
+// Modified returns true if JSONDiff has at least one Delta.
+func (d *JSONDiff) Modified() bool {
+	return len(d.ds) > 0
+}
 
-	// decrease indentation for remaining lines
-	indentRE := regexp.MustCompile(`(\n.)\s{2}`)
-	return indentRE.ReplaceAllString(unwrapped, "$1"), nil
+// Format returns a string representation of the differences between two JSON values.
+// It's equivalent to FormatWithOptions(FormatOptions{Coloring: coloring}).
+//
+// Changes within strings represented by TextDiffs are highlighted: the old
+// value highlights deleted text (like [-this-]) and the new value highlights
+// inserted text (like {+this+}). If coloring is enabled, highlighted text is
+// displayed in reverse video instead.
+func (d *JSONDiff) Format(coloring bool) (string, error) {
+	return d.FormatWithOptions(FormatOptions{Coloring: coloring})
 }
 
 // JSONDiffer compares JSON strings.
 type JSONDiffer struct {
 	// BasicEqualer specifies how values of basic types should be compared.
+	// In order to normalize all strings in the JSON values (but not the keys
+	// of objects), use e.g. TolerantBasicEqualer{StringTransformer: Chain{...}}.
 	BasicEqualer
+	// TextDiffMinimumLength specifies how long (in bytes) at least one of two
+	// different strings must be for the difference to be represented by a
+	// TextDiff rather than a Modified delta. If it's 0, TextDiffs aren't used.
+	TextDiffMinimumLength int
+	// TextDiffGranularity specifies how changes within strings represented by
+	// TextDiffs are highlighted when formatting the differences.
+	TextDiffGranularity Granularity
+	// Limits restrict the resources a comparison may use.
+	Limits Limits
+	// Parallelism specifies whether the elements of large objects and arrays
+	// are compared concurrently by Compare. It doesn't apply to Equal.
+	Parallelism Parallelism
+	// MissingEqualsNull specifies whether an object element whose value is
+	// null is considered equal to a missing element. If it is, it's tolerated.
+	MissingEqualsNull bool
+	// MissingEqualsEmpty specifies whether an object element whose value is
+	// an empty array or object is considered equal to a missing element.
+	// If it is, it's tolerated.
+	MissingEqualsEmpty bool
+	// NullEqualsEmpty specifies whether null is considered equal to an empty
+	// array or object. If it is, it's tolerated.
+	NullEqualsEmpty bool
+	// MissingEqualsZero specifies whether an object element whose value is
+	// null, false, 0, "" or an empty array or object is considered equal to a
+	// missing element. If it is, it's tolerated.
+	MissingEqualsZero bool
+	// Defaults specifies the default values of object elements, which are
+	// considered equal to missing elements (and tolerated). The keys are path
+	// patterns like FieldFilter.Paths, e.g. "/servers/*/retries", and the
+	// values are converted to JSON values, e.g. 3. If there's a default value
+	// for an element, it takes precedence over the other options.
+	Defaults map[string]interface{}
+	// Subset specifies whether the left (expected) value only needs to be a
+	// subset of the right (actual) value: object and array elements that only
+	// exist on the right side are ignored, so differences are only reported
+	// for left values.
+	Subset bool
+	// SubsetArrays specifies how the elements of arrays are matched if
+	// Subset is true.
+	SubsetArrays ArrayMatching
 }
 
 // Equal determines if two JSON strings represent the same value.
-// Returns an error iff the strings don't adhere to the JSON syntax.
+// It's equivalent to EqualContext(context.Background(), left, right).
 func (jd JSONDiffer) Equal(left, right []byte) (bool, error) {
-	d, err := jd.Compare(left, right)
-	if err != nil {
-		return false, err
+	return jd.EqualContext(context.Background(), left, right)
+}
+
+// EqualContext determines if two JSON strings represent the same value.
+// Returns an error if the strings don't adhere to the JSON syntax, and a
+// *LimitError if one of the Limits is exceeded or ctx is done before the
+// comparison is finished.
+//
+// Unlike Compare, Equal stops at the first difference and doesn't describe
+// the differences, which makes it considerably faster. Identical strings are
+// only decoded once and, unless Limits.MaxDepth or Limits.MaxNodes need to be
+// enforced, considered equal without comparing them (assuming that the
+// BasicEqualer considers identical values equal).
+func (jd JSONDiffer) EqualContext(ctx context.Context, left, right []byte) (bool, error) {
+	c := newJSONComparison(jd, ctx)
+	if c.err != nil {
+		return false, c.err
+	}
+	var l, r interface{}
+	if bytes.Equal(left, right) {
+		// json.Valid accepts values that json.Unmarshal rejects, e.g. 1e400
+		if err := json.Unmarshal(left, &l); err != nil {
+			return false, err
+		}
+		if jd.Limits.MaxDepth == 0 && jd.Limits.MaxNodes == 0 {
+			return true, nil
+		}
+		r = l
+	} else {
+		var err error
+		if l, r, err = unmarshalPair(left, right); err != nil {
+			return false, err
+		}
 	}
-	return !d.Modified(), nil
+	if same := c.equal(l, r); c.err == nil {
+		return same, nil
+	}
+	return false, c.err
 }
 
 // Compare returns the differences between two JSON strings.
-// Returns an error iff the strings don't adhere to the JSON syntax.
+// It's equivalent to CompareContext(context.Background(), left, right).
 func (jd JSONDiffer) Compare(left, right []byte) (*JSONDiff, error) {
-	var l, r interface{}
+	return jd.CompareContext(context.Background(), left, right)
+}
 
-	if err := json.Unmarshal(left, &l); err != nil {
+// CompareContext returns the differences between two JSON strings.
+// Returns an error if the strings don't adhere to the JSON syntax, and a
+// *LimitError if one of the Limits is exceeded or ctx is done before the
+// comparison is finished.
+func (jd JSONDiffer) CompareContext(ctx context.Context, left, right []byte) (*JSONDiff, error) {
+	l, r, err := unmarshalPair(left, right)
+	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(right, &r); err != nil {
-		return nil, err
+	return jd.compareDecoded(ctx, l, r)
+}
+
+// unmarshalPair decodes two JSON strings.
+func unmarshalPair(left, right []byte) (l, r interface{}, err error) {
+	if err = json.Unmarshal(left, &l); err != nil {
+		return nil, nil, err
 	}
+	if err = json.Unmarshal(right, &r); err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
 
-	// add explicit root in case the values are arrays or plain values (not objects)
-	leftMap := map[string]interface{}{"$": l}
-	rightMap := map[string]interface{}{"$": r}
-	d := jd.compareMaps(leftMap, rightMap)
-	d.left = leftMap
+// compareDecoded returns the differences between two decoded JSON values.
+func (jd JSONDiffer) compareDecoded(ctx context.Context, l, r interface{}) (*JSONDiff, error) {
+	c := newJSONComparison(jd, ctx)
+	d := &JSONDiff{
+		// add explicit root in case the values are arrays or plain values (not objects)
+		left:        map[string]interface{}{"$": l},
+		right:       r,
+		granularity: jd.TextDiffGranularity,
+		subset:      jd.Subset,
+		matching:    jd.SubsetArrays,
+	}
+	if same, delta := c.compare(gojsondiff.Name("$"), l, r); !same {
+		d.ds = []gojsondiff.Delta{delta}
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	d.tolerances = c.tolerances
 	return d, nil
 }
 
-// cf. https://github.com/yudai/gojsondiff/blob/master/gojsondiff.go#L66-L74
-func (jd JSONDiffer) compareMaps(left, right map[string]interface{}) *JSONDiff {
-	ds := jd.mapDeltas(left, right)
-	return &JSONDiff{ds: ds}
+// jsonComparison holds the state of a single comparison of two JSON values.
+type jsonComparison struct {
+	JSONDiffer
+	// path specifies where the values that are currently being compared are
+	// located within the JSON values that are being compared as a whole.
+	path       Path
+	tolerances []Tolerance
+	// ctx is checked for every value that's compared (if it isn't nil).
+	ctx context.Context
+	// counters is shared by all forks of the comparison.
+	counters *comparisonCounters
+	pool     workerPool
+	defaults []pathDefault
+	// err is set if the comparison was aborted (or if it couldn't be started,
+	// because the Defaults are invalid). The comparison then unwinds without
+	// comparing any more values.
+	err error
+}
+
+// comparisonCounters count the values compared and the differences found so
+// far, so the Limits can be enforced. They're accessed atomically.
+type comparisonCounters struct {
+	nodes, differences int64
+}
+
+func newJSONComparison(jd JSONDiffer, ctx context.Context) *jsonComparison {
+	c := &jsonComparison{
+		JSONDiffer: jd,
+		ctx:        ctx,
+		counters:   &comparisonCounters{},
+		pool:       newWorkerPool(jd.Parallelism),
+	}
+	c.defaults, c.err = compileDefaults(jd.Defaults, normalizeJSON)
+	return c
+}
+
+// fork returns a new comparison of values nested within the values that are
+// currently being compared, which can be used concurrently with c.
+func (c *jsonComparison) fork() *jsonComparison {
+	return &jsonComparison{
+		JSONDiffer: c.JSONDiffer,
+		path:       c.path.clone(),
+		ctx:        c.ctx,
+		counters:   c.counters,
+		pool:       c.pool,
+		defaults:   c.defaults,
+	}
+}
+
+// deltas calls fn for n elements of the values that are currently being
+// compared, and returns the Deltas it returns (except nil) in order. If the
+// values are large enough, the elements are compared concurrently by forks of
+// the comparison, whose results are then merged as if they'd been compared
+// sequentially.
+func (c *jsonComparison) deltas(n int, fn func(c *jsonComparison, i int) gojsondiff.Delta) []gojsondiff.Delta {
+	var ds []gojsondiff.Delta
+
+	chunks := c.pool.chunks(c.Parallelism.Threshold, n)
+	if chunks == 1 {
+		for i := 0; i < n && c.err == nil; i++ {
+			if d := fn(c, i); d != nil {
+				ds = append(ds, d)
+			}
+		}
+		return ds
+	}
+
+	results := make([]gojsondiff.Delta, n)
+	forks := make([]*jsonComparison, chunks)
+	// panics holds the values of panics raised by fn (e.g. in a custom
+	// BasicEqualer), which are raised again by the calling goroutine.
+	panics := make([]interface{}, chunks)
+	panicked := make([]bool, chunks)
+	c.pool.split(n, chunks, func(chunk, start, end int) {
+		f := c.fork()
+		forks[chunk] = f
+		panicked[chunk] = true
+		defer func() {
+			if panicked[chunk] {
+				panics[chunk] = recover()
+			}
+		}()
+		for i := start; i < end && f.err == nil; i++ {
+			results[i] = fn(f, i)
+		}
+		panicked[chunk] = false
+	})
+	for chunk, f := range forks {
+		if panicked[chunk] {
+			panic(panics[chunk])
+		}
+		c.tolerances = append(c.tolerances, f.tolerances...)
+		if c.err == nil {
+			c.err = f.err
+		}
+	}
+	for _, d := range results {
+		if d != nil {
+			ds = append(ds, d)
+		}
+	}
+	return ds
 }
 
 // cf. https://github.com/yudai/gojsondiff/blob/master/gojsondiff.go#L235-L279
-func (jd JSONDiffer) compare(pos gojsondiff.Position, left, right interface{}) (bool, gojsondiff.Delta) {
+func (c *jsonComparison) compare(pos gojsondiff.Position, left, right interface{}) (bool, gojsondiff.Delta) {
+	if !c.visit() {
+		return true, nil
+	}
+
 	if reflect.TypeOf(left) != reflect.TypeOf(right) {
+		if rule := c.nullRule(left, right); rule != "" {
+			c.tolerate(c.path, left, right, rule)
+			return true, nil
+		}
+		c.difference(c.path)
 		return false, gojsondiff.NewModified(pos, left, right)
 	}
 
 	switch l := left.(type) {
 	case []interface{}:
-		if ds := jd.sliceDeltas(l, right.([]interface{})); len(ds) > 0 {
+		if ds := c.sliceDeltas(l, right.([]interface{})); len(ds) > 0 {
 			return false, gojsondiff.NewArray(pos, ds)
 		}
 	case map[string]interface{}:
-		if ds := jd.mapDeltas(l, right.(map[string]interface{})); len(ds) > 0 {
+		if ds := c.mapDeltas(l, right.(map[string]interface{})); len(ds) > 0 {
 			return false, gojsondiff.NewObject(pos, ds)
 		}
 	default:
-		return jd.valueDelta(pos, left, right)
+		return c.valueDelta(pos, left, right)
 	}
 
 	return true, nil
 }
 
+// compareChild compares two values nested within the values that are currently being compared.
+func (c *jsonComparison) compareChild(pos gojsondiff.Position, left, right interface{}) (bool, gojsondiff.Delta) {
+	c.path = append(c.path, pos)
+	same, d := c.compare(pos, left, right)
+	c.path = c.path[:len(c.path)-1]
+	return same, d
+}
+
+// equal determines if two JSON values are equal like compare, but it stops at
+// the first difference and doesn't create any Deltas or Tolerances.
+func (c *jsonComparison) equal(left, right interface{}) bool {
+	if !c.visit() {
+		return false
+	}
+
+	if reflect.TypeOf(left) != reflect.TypeOf(right) {
+		return c.nullRule(left, right) != ""
+	}
+
+	switch l := left.(type) {
+	case []interface{}:
+		r := right.([]interface{})
+		if c.Subset {
+			return c.equalSubsetSlices(l, r)
+		}
+		if len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !c.equalChild(gojsondiff.Index(i), l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		r := right.(map[string]interface{})
+		missing := c.MissingEqualsNull || c.MissingEqualsEmpty || c.MissingEqualsZero || c.defaults != nil
+		if len(l) != len(r) && !missing && (len(l) > len(r) || !c.Subset) {
+			return false
+		}
+		for key, leftVal := range l {
+			if rightVal, ok := r[key]; ok {
+				if !c.equalChild(gojsondiff.Name(key), leftVal, rightVal) {
+					return false
+				}
+			} else if c.missingRule(c.path.child(gojsondiff.Name(key)), leftVal) == "" {
+				return false
+			}
+		}
+		if missing && !c.Subset {
+			for key, rightVal := range r {
+				if _, ok := l[key]; !ok && c.missingRule(c.path.child(gojsondiff.Name(key)), rightVal) == "" {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return c.valueEqual(left, right)
+}
+
+// equalChild determines if two values nested within the values that are currently being compared are equal.
+func (c *jsonComparison) equalChild(pos gojsondiff.Position, left, right interface{}) bool {
+	c.path = append(c.path, pos)
+	same := c.equal(left, right)
+	c.path = c.path[:len(c.path)-1]
+	return same
+}
+
+// visit counts a value that's about to be compared and checks the Limits and
+// the context. It returns false if the comparison was aborted.
+func (c *jsonComparison) visit() bool {
+	if c.err != nil {
+		return false
+	}
+	nodes := atomic.AddInt64(&c.counters.nodes, 1)
+	switch {
+	case c.Limits.MaxNodes > 0 && nodes > int64(c.Limits.MaxNodes):
+		c.abort(LimitNodes, c.path, nil)
+	case c.Limits.MaxDepth > 0 && len(c.path) > c.Limits.MaxDepth:
+		c.abort(LimitDepth, c.path, nil)
+	case c.ctx != nil:
+		select {
+		case <-c.ctx.Done():
+			c.abort(LimitContext, c.path, c.ctx.Err())
+		default:
+		}
+	}
+	return c.err == nil
+}
+
+// difference counts a difference at the given path and checks Limits.MaxDifferences.
+func (c *jsonComparison) difference(path Path) {
+	differences := atomic.AddInt64(&c.counters.differences, 1)
+	if c.Limits.MaxDifferences > 0 && differences > int64(c.Limits.MaxDifferences) {
+		c.abort(LimitDifferences, path, nil)
+	}
+}
+
+// abort aborts the comparison with a *LimitError.
+func (c *jsonComparison) abort(limit Limit, path Path, err error) {
+	c.err = &LimitError{Limit: limit, Path: path.clone(), Err: err}
+}
+
 // cf. https://github.com/yudai/gojsondiff/blob/master/gojsondiff.go#L125-L233
 // Note that this implementation is much more primitive. There's no attempt to
 // find a longest common sequence and base differences on that. We just compare
 // values index by index.
-func (jd JSONDiffer) sliceDeltas(left, right []interface{}) []gojsondiff.Delta {
-	var ds []gojsondiff.Delta
-
-	for i, leftVal := range left {
-		if i < len(right) {
-			if same, d := jd.compare(gojsondiff.Index(i), leftVal, right[i]); !same {
-				ds = append(ds, d)
-			}
-		} else {
-			ds = append(ds, gojsondiff.NewDeleted(gojsondiff.Index(i), leftVal))
+func (c *jsonComparison) sliceDeltas(left, right []interface{}) []gojsondiff.Delta {
+	if c.Subset && c.SubsetArrays != IndexMatching {
+		return c.subsetSliceDeltas(left, right)
+	}
+	common := len(left)
+	if len(right) < common {
+		common = len(right)
+	}
+	ds := c.deltas(common, func(c *jsonComparison, i int) gojsondiff.Delta {
+		if same, d := c.compareChild(gojsondiff.Index(i), left[i], right[i]); !same {
+			return d
 		}
+		return nil
+	})
+
+	for i := common; i < len(left) && c.err == nil; i++ {
+		c.difference(c.path.child(gojsondiff.Index(i)))
+		ds = append(ds, gojsondiff.NewDeleted(gojsondiff.Index(i), left[i]))
 	}
 
-	for i := len(left); i < len(right); i++ {
+	for i := common; i < len(right) && c.err == nil && !c.Subset; i++ {
+		c.difference(c.path.child(gojsondiff.Index(i)))
 		ds = append(ds, gojsondiff.NewAdded(gojsondiff.Index(i), right[i]))
 	}
 
+	if c.err != nil {
+		return nil
+	}
 	return ds
 }
 
 // cf. https://github.com/yudai/gojsondiff/blob/master/gojsondiff.go#L86-L112
-func (jd JSONDiffer) mapDeltas(left, right map[string]interface{}) []gojsondiff.Delta {
-	var ds []gojsondiff.Delta
-
+func (c *jsonComparison) mapDeltas(left, right map[string]interface{}) []gojsondiff.Delta {
 	keys := sortedKeys(left) // stabilize delta order
-	for _, key := range keys {
+	ds := c.deltas(len(keys), func(c *jsonComparison, i int) gojsondiff.Delta {
+		key := keys[i]
 		if rightVal, ok := right[key]; ok {
-			if same, d := jd.compare(gojsondiff.Name(key), left[key], rightVal); !same {
-				ds = append(ds, d)
+			if same, d := c.compareChild(gojsondiff.Name(key), left[key], rightVal); !same {
+				return d
 			}
-		} else {
-			ds = append(ds, gojsondiff.NewDeleted(gojsondiff.Name(key), left[key]))
+			return nil
 		}
-	}
+		if rule := c.missingRule(c.path.child(gojsondiff.Name(key)), left[key]); rule != "" {
+			c.tolerate(c.path.child(gojsondiff.Name(key)), left[key], nil, rule)
+			return nil
+		}
+		c.difference(c.path.child(gojsondiff.Name(key)))
+		return gojsondiff.NewDeleted(gojsondiff.Name(key), left[key])
+	})
 
+	if c.Subset {
+		return ds
+	}
 	keys = sortedKeys(right) // stabilize delta order
 	for _, key := range keys {
+		if c.err != nil {
+			return nil
+		}
 		if _, ok := left[key]; !ok {
+			if rule := c.missingRule(c.path.child(gojsondiff.Name(key)), right[key]); rule != "" {
+				c.tolerate(c.path.child(gojsondiff.Name(key)), nil, right[key], rule)
+				continue
+			}
+			c.difference(c.path.child(gojsondiff.Name(key)))
 			ds = append(ds, gojsondiff.NewAdded(gojsondiff.Name(key), right[key]))
 		}
 	}
@@ -166,28 +575,54 @@ func (jd JSONDiffer) mapDeltas(left, right map[string]interface{}) []gojsondiff.
 
 // valueDelta returns the Delta (if any) for two basic values (null, boolean, number, string).
 // Rather than just using reflect.DeepEqual(), as gojsondiff does, we use a custom BasicEqualer.
-func (jd *JSONDiffer) valueDelta(pos gojsondiff.Position, left, right interface{}) (bool, gojsondiff.Delta) {
-	var same bool
+// If the values aren't identical, but the BasicEqualer considers them equal,
+// we remember that they were tolerated.
+func (c *jsonComparison) valueDelta(pos gojsondiff.Position, left, right interface{}) (bool, gojsondiff.Delta) {
+	if !c.valueEqual(left, right) {
+		c.difference(c.path)
+		return false, c.modifiedDelta(pos, left, right)
+	}
+
+	if left != right {
+		c.tolerances = append(c.tolerances, Tolerance{
+			Path:  c.path.clone(),
+			Left:  left,
+			Right: right,
+			Rule:  describeTolerance(c.BasicEqualer, left, right),
+		})
+	}
 
+	return true, nil
+}
+
+// valueEqual determines if two basic values of the same type are equal.
+func (c *jsonComparison) valueEqual(left, right interface{}) bool {
 	switch l := left.(type) {
 	case nil:
-		same = left == right
+		return left == right
 	case bool:
-		same = jd.Bool(l, right.(bool))
+		return c.Bool(l, right.(bool))
 	case float64:
-		same = jd.Float64(l, right.(float64))
+		return c.Float64(l, right.(float64))
 	case string:
-		same = jd.String(l, right.(string))
-	default:
-		// should never happen (https://golang.org/pkg/encoding/json/#Unmarshal)
-		same = reflect.DeepEqual(left, right)
+		return c.String(l, right.(string))
 	}
+	// should never happen (https://golang.org/pkg/encoding/json/#Unmarshal)
+	return reflect.DeepEqual(left, right)
+}
 
-	if !same {
-		return false, gojsondiff.NewModified(pos, left, right)
+// modifiedDelta returns a TextDiff for long strings and a Modified delta otherwise.
+// Whether the values are equal is still determined by the BasicEqualer;
+// the TextDiff merely describes the differences in more detail.
+func (c *jsonComparison) modifiedDelta(pos gojsondiff.Position, left, right interface{}) gojsondiff.Delta {
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if lok && rok && c.TextDiffMinimumLength > 0 &&
+		(len(l) >= c.TextDiffMinimumLength || len(r) >= c.TextDiffMinimumLength) {
+		patches := diffmatchpatch.New().PatchMake(l, r)
+		return gojsondiff.NewTextDiff(pos, patches, left, right)
 	}
-
-	return true, nil
+	return gojsondiff.NewModified(pos, left, right)
 }
 
 // cf. https://github.com/yudai/gojsondiff/blob/master/gojsondiff.go#L409-L416
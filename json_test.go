@@ -1,14 +1,16 @@
 package compare
 
 import (
+	"bytes"
 	"fmt"
+	"reflect"
 	"regexp"
 	"testing"
 	"time"
 )
 
 func ExampleJSONDiffer_Compare() {
-	jd := JSONDiffer{TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
 	d, err := jd.Compare(
 		[]byte(`{"x": 1.6, "y": [3.8, "hello"]}`),
 		[]byte(`{"x": 1.57, "y": [3.6, "hello"], "z": 0}`))
@@ -45,6 +47,7 @@ func TestJSONDiffer_Equal_exact(t *testing.T) {
 		{``, ``, false, "unexpected end of JSON input"},
 		{`""`, ``, false, "unexpected end of JSON input"},
 		{`undefined`, `undefined`, false, "invalid character 'u' looking for beginning of value"},
+		{`1e400`, `1e400`, false, "json: cannot unmarshal number 1e400 into Go value of type float64"},
 		{`null`, `null`, true, ""},
 		{`null`, `false`, false, ""},
 		{`null`, `""`, false, ""},
@@ -87,7 +90,7 @@ func TestJSONDiffer_Equal_exact(t *testing.T) {
 		},
 	}
 	// since we specify no tolerances, the equaler will compare values exactly
-	e := &JSONDiffer{TolerantBasicEqualer{}}
+	e := &JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
 	for _, tc := range tcs {
 		actual, err := e.Equal([]byte(tc.a), []byte(tc.b))
 		if err != nil {
@@ -126,7 +129,7 @@ func TestJSONDiffer_Equal_tolerant(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	e := &JSONDiffer{TolerantBasicEqualer{
+	e := &JSONDiffer{BasicEqualer: TolerantBasicEqualer{
 		Float64Tolerance: 0.05,
 		// ignore everything after last underscore
 		StringTransformer: SubstringDeleter{Regexp: regexp.MustCompile("_[^_]*$")},
@@ -143,6 +146,36 @@ func TestJSONDiffer_Equal_tolerant(t *testing.T) {
 	}
 }
 
+func TestJSONDiffer_Equal_compare(t *testing.T) {
+	// Equal must agree with Compare
+	tcs := []string{
+		`null`,
+		`{"a": 1, "b": [1, 2, {"c": "foo "}], "d": {"e": true}}`,
+		`{"a": 1.05, "b": [1, 2, {"c": "foo"}], "d": {"e": true}}`,
+		`{"a": 1, "b": [1, 2, {"c": "bar"}], "d": {"e": true}}`,
+		`{"a": 1, "b": [1, 2], "d": {"e": true}}`,
+		`{"a": 1, "b": [1, 2, {"c": "foo"}], "d": {"e": null}}`,
+		`{"a": 1, "b": [1, 2, {"c": "foo"}], "d": {"f": true}}`,
+		`{"a": 1, "b": [1, 2, {"c": "foo"}]}`,
+		`[1, 2, {"c": "foo"}]`,
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1, StringTransformer: SpaceTrimmer{}}}
+	for _, a := range tcs {
+		for _, b := range tcs {
+			d, err := jd.Compare([]byte(a), []byte(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := jd.Equal([]byte(a), []byte(b))
+			if err != nil {
+				t.Errorf("[%v == %v] %v", a, b, err)
+			} else if actual != !d.Modified() {
+				t.Errorf("[%v == %v] expected %v; got %v", a, b, !d.Modified(), actual)
+			}
+		}
+	}
+}
+
 func TestJSONDiffer_Compare(t *testing.T) {
 	type testCase struct {
 		a        string
@@ -186,7 +219,7 @@ func TestJSONDiffer_Compare(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	e := &JSONDiffer{TolerantBasicEqualer{
+	e := &JSONDiffer{BasicEqualer: TolerantBasicEqualer{
 		Float64Tolerance: 0.05,
 		// ignore everything after last underscore
 		StringTransformer: SubstringDeleter{Regexp: regexp.MustCompile("_[^_]*$")},
@@ -207,3 +240,159 @@ func TestJSONDiffer_Compare(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONDiff_Tolerances(t *testing.T) {
+	jd := JSONDiffer{
+		BasicEqualer: TolerantBasicEqualer{
+			Float64Tolerance:  0.1,
+			StringTransformer: SpaceTrimmer{},
+			StringEqualer:     FuzzyStringEqualer{MaxDistance: 1},
+		},
+	}
+	d, err := jd.Compare(
+		[]byte(`{"a": [1.6, 2], "b": {"c": "foo ", "d": "bar", "e": "baz"}, "f": null}`),
+		[]byte(`{"a": [1.57, 2], "b": {"c": "foo", "d": "baz", "e": "baz"}, "f": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"/a/0 1.6 1.57 Float64Tolerance: 0.1",
+		"/b/c foo  foo StringTransformer",
+		"/b/d bar baz StringEqualer",
+	}
+	actual := d.Tolerances()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v tolerances; got %v", len(expected), actual)
+	}
+	for i, tol := range actual {
+		if s := fmt.Sprintf("%s %v %v %s", tol.Path.Pointer(), tol.Left, tol.Right, tol.Rule); s != expected[i] {
+			t.Errorf("expected %q; got %q", expected[i], s)
+		}
+	}
+
+	// if the BasicEqualer can't describe tolerances, its type is used as the rule
+	jd = JSONDiffer{BasicEqualer: embeddingEqualer{TolerantBasicEqualer{Float64Tolerance: 0.1}}}
+	if d, err = jd.Compare([]byte(`1.6`), []byte(`1.57`)); err != nil {
+		t.Fatal(err)
+	}
+	if actual := d.Tolerances(); len(actual) != 1 || actual[0].Rule != "Float64Tolerance: 0.1" {
+		t.Errorf("expected promoted description; got %v", actual)
+	}
+	jd = JSONDiffer{BasicEqualer: looseEqualer{}}
+	if d, err = jd.Compare([]byte(`true`), []byte(`false`)); err != nil {
+		t.Fatal(err)
+	}
+	if actual := d.Tolerances(); len(actual) != 1 || actual[0].Rule != "compare.looseEqualer" || len(actual[0].Path) != 0 {
+		t.Errorf("expected compare.looseEqualer at root; got %v", actual)
+	}
+}
+
+// embeddingEqualer promotes the methods of the embedded TolerantBasicEqualer.
+type embeddingEqualer struct {
+	TolerantBasicEqualer
+}
+
+// looseEqualer considers all values of the same type equal.
+type looseEqualer struct{}
+
+func (looseEqualer) Bool(a, b bool) bool             { return true }
+func (looseEqualer) Int64(a, b int64) bool           { return true }
+func (looseEqualer) Uint64(a, b uint64) bool         { return true }
+func (looseEqualer) Float64(a, b float64) bool       { return true }
+func (looseEqualer) Complex128(a, b complex128) bool { return true }
+func (looseEqualer) String(a, b string) bool         { return true }
+
+func TestJSONDiff_Differences(t *testing.T) {
+	type testCase struct {
+		left     string
+		right    string
+		expected []string
+	}
+
+	tcs := []testCase{
+		{left: `1`, right: `1`},
+		{left: `1`, right: `2`, expected: []string{" replace 1 2 Float64"}},
+		{left: `"a"`, right: `true`, expected: []string{" replace a true type"}},
+		{
+			left:  `{"a": [1, 2, 3], "b": {"c": true, "d": null}, "e/f": "x"}`,
+			right: `{"a": [1, 4], "b": {"c": false, "g": "y"}, "e/f": "x", "h": null}`,
+			expected: []string{
+				"/a/1 replace 2 4 Float64",
+				"/a/2 remove 3 <nil> ",
+				"/b/c replace true false Bool",
+				"/b/d remove <nil> <nil> ",
+				"/b/g add <nil> y ",
+				"/h add <nil> <nil> ",
+			},
+		},
+	}
+
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, TextDiffMinimumLength: 1}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.left), []byte(tc.right))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual []string
+		for _, diff := range d.Differences() {
+			actual = append(actual, fmt.Sprintf("%s %s %v %v %s",
+				diff.Path.Pointer(), diff.Operation, diff.Left, diff.Right, diff.Comparator))
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("[%v == %v] expected %q; got %q", tc.left, tc.right, tc.expected, actual)
+		}
+	}
+}
+
+// benchmarkPayload returns a large JSON object, whose last element is replaced by last.
+func benchmarkPayload(last string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"items": [`)
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&buf, `{"id": %d, "name": "item %d", "price": %d.99, "tags": ["a", "b", "c"], "available": true},`, i, i, i)
+	}
+	buf.WriteString(last)
+	buf.WriteString(`]}`)
+	return buf.Bytes()
+}
+
+func benchmarkEqual(b *testing.B, left, right []byte, equal func(JSONDiffer, []byte, []byte) (bool, error)) {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	b.SetBytes(int64(len(left) + len(right)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := equal(jd, left, right); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// compareEqual is how Equal used to be implemented.
+func compareEqual(jd JSONDiffer, left, right []byte) (bool, error) {
+	d, err := jd.Compare(left, right)
+	if err != nil {
+		return false, err
+	}
+	return !d.Modified(), nil
+}
+
+func BenchmarkJSONDiffer_Equal(b *testing.B) {
+	type benchmark struct {
+		name        string
+		left, right []byte
+	}
+	bms := []benchmark{
+		{"identical", benchmarkPayload(`{}`), benchmarkPayload(`{}`)},
+		{"equal", benchmarkPayload(`{"price": 1.0}`), benchmarkPayload(`{"price": 1.05}`)},
+		{"different", benchmarkPayload(`{"price": 1.0}`), benchmarkPayload(`{"price": 2.0}`)},
+		{"replaced", benchmarkPayload(`{}`), []byte(`{"items": []}`)},
+	}
+	for _, bm := range bms {
+		b.Run(bm.name+"/Equal", func(b *testing.B) {
+			benchmarkEqual(b, bm.left, bm.right, JSONDiffer.Equal)
+		})
+		b.Run(bm.name+"/Compare", func(b *testing.B) {
+			benchmarkEqual(b, bm.left, bm.right, compareEqual)
+		})
+	}
+}
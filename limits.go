@@ -0,0 +1,61 @@
+package compare
+
+import (
+	"fmt"
+)
+
+// Limits restrict the resources a comparison may use. Zero values mean that
+// there's no limit.
+//
+// Note that JSON strings are decoded as a whole before they're compared, so
+// Limits don't restrict the size of the input; use e.g. http.MaxBytesReader
+// for that.
+type Limits struct {
+	// MaxDepth is the maximum depth of nested values that are compared, e.g. 2
+	// for the value at "/a/b".
+	MaxDepth int
+	// MaxNodes is the maximum number of values (including objects and arrays)
+	// that are compared.
+	MaxNodes int
+	// MaxDifferences is the maximum number of differences that are collected.
+	// It doesn't apply to Equal, which stops at the first difference anyway.
+	MaxDifferences int
+}
+
+// Limit identifies why a comparison was aborted.
+type Limit string
+
+const (
+	// LimitDepth marks comparisons that exceeded Limits.MaxDepth.
+	LimitDepth Limit = "MaxDepth"
+	// LimitNodes marks comparisons that exceeded Limits.MaxNodes.
+	LimitNodes Limit = "MaxNodes"
+	// LimitDifferences marks comparisons that exceeded Limits.MaxDifferences.
+	LimitDifferences Limit = "MaxDifferences"
+	// LimitContext marks comparisons whose context was canceled or whose
+	// deadline was exceeded.
+	LimitContext Limit = "Context"
+)
+
+// A LimitError is returned if a comparison was aborted, because one of the
+// Limits was exceeded or because its context is done.
+type LimitError struct {
+	// Limit specifies why the comparison was aborted.
+	Limit Limit
+	// Path specifies where the comparison was aborted.
+	Path Path
+	// Err is the context's error if the context is done, and nil otherwise.
+	Err error
+}
+
+func (e *LimitError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("comparison aborted at %q: %v", e.Path.Pointer(), e.Err)
+	}
+	return fmt.Sprintf("comparison aborted at %q: %s exceeded", e.Path.Pointer(), e.Limit)
+}
+
+// Unwrap returns the context's error, if any.
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
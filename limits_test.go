@@ -0,0 +1,158 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleLimits() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Limits: Limits{MaxDepth: 2}}
+	_, err := jd.Compare([]byte(`{"a": [1, [2]]}`), []byte(`{"a": [1, [3]]}`))
+	fmt.Println(err)
+	if le, ok := err.(*LimitError); ok {
+		fmt.Println(le.Limit, le.Path)
+	}
+	// Output:
+	// comparison aborted at "/a/1/0": MaxDepth exceeded
+	// MaxDepth a[1][0]
+}
+
+func TestJSONDiffer_CompareContext_limits(t *testing.T) {
+	type testCase struct {
+		a       string
+		b       string
+		limits  Limits
+		compare string // expected error of CompareContext (empty if there's no error)
+		equal   string // expected error of EqualContext (empty if there's no error)
+	}
+	tcs := []testCase{
+		{`[[[1]]]`, `[[[ 1 ]]]`, Limits{MaxDepth: 3}, "", ""},
+		{`[[[1]]]`, `[[[2]]]`, Limits{MaxDepth: 3}, "", ""},
+		{`[[[1]]]`, `[[[ 1 ]]]`, Limits{MaxDepth: 2}, `"/0/0/0": MaxDepth`, `"/0/0/0": MaxDepth`},
+		{`[[[1]]]`, `[[1]]`, Limits{MaxDepth: 2}, "", ""},
+		{`{"a": {"b": 1}}`, `{"a": {"b": 1.0}}`, Limits{MaxDepth: 1}, `"/a/b": MaxDepth`, `"/a/b": MaxDepth`},
+		{`{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, Limits{MaxNodes: 3}, "", ""},
+		{`{"a": 1, "b": 2}`, `{"b": 2, "a": 1, "c": 3}`, Limits{MaxNodes: 2}, `"/b": MaxNodes`, ""},
+		{`[1, 2, 3]`, `[1, 2, 3.0]`, Limits{MaxNodes: 2}, `"/1": MaxNodes`, `"/1": MaxNodes`},
+		{`[1, 2, 3]`, `[4, 5, 6]`, Limits{MaxNodes: 2}, `"/1": MaxNodes`, ""},
+		{`[1, 2, 3]`, `[4, 5, 6]`, Limits{MaxDifferences: 3}, "", ""},
+		{`[1, 2, 3]`, `[4, 5, 6]`, Limits{MaxDifferences: 2}, `"/2": MaxDifferences`, ""},
+		{`[1, 2]`, `[1, 2, 3, 4]`, Limits{MaxDifferences: 1}, `"/3": MaxDifferences`, ""},
+		{`{"a": 1, "b": 2}`, `{"c": 3}`, Limits{MaxDifferences: 2}, `"/c": MaxDifferences`, ""},
+		{`{"a": {"b": 1}, "c": 2}`, `{"a": "x", "c": 3}`, Limits{MaxDifferences: 1}, `"/c": MaxDifferences`, ""},
+		{`1`, `"1"`, Limits{MaxDepth: 1, MaxNodes: 1, MaxDifferences: 1}, "", ""},
+		{`[[[1]]]`, `[[[1]]]`, Limits{MaxDepth: 2}, `"/0/0/0": MaxDepth`, `"/0/0/0": MaxDepth`},
+		{`[1, 2, 3]`, `[1, 2, 3]`, Limits{MaxNodes: 2}, `"/1": MaxNodes`, `"/1": MaxNodes`},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Limits: tc.limits}
+		d, err := jd.CompareContext(context.Background(), []byte(tc.a), []byte(tc.b))
+		if actual := describeLimitError(err); actual != tc.compare {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.compare, err)
+		} else if err == nil && d == nil {
+			t.Errorf("[%v == %v] expected diff", tc.a, tc.b)
+		}
+		// EqualContext stops at the first difference, before it may exceed a limit
+		_, err = jd.EqualContext(context.Background(), []byte(tc.a), []byte(tc.b))
+		if actual := describeLimitError(err); actual != tc.equal {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.equal, err)
+		}
+	}
+}
+
+func TestJSONDiffer_CompareContext_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	a, b := []byte(`{"a": [1, 2]}`), []byte(`{"a": [1, 3]}`)
+	if _, err := jd.CompareContext(ctx, a, b); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v; got %v", context.Canceled, err)
+	}
+	if _, err := jd.EqualContext(ctx, a, b); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v; got %v", context.Canceled, err)
+	}
+	// identical strings aren't compared at all
+	if same, err := jd.EqualContext(ctx, a, a); err != nil || !same {
+		t.Errorf("expected equal; got %v (%v)", same, err)
+	}
+
+	var le *LimitError
+	_, err := jd.CompareContext(ctx, a, b)
+	if !errors.As(err, &le) || le.Limit != LimitContext || le.Path.Pointer() != "" {
+		t.Errorf("expected %v at root; got %v", LimitContext, err)
+	}
+}
+
+func TestJSONDiffer_CompareReaders_limits(t *testing.T) {
+	// values that are decoded must not be compared partially
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Limits: Limits{MaxDepth: 2}}
+	_, err := jd.CompareReaders(strings.NewReader(`{"a": 1, "b": [[1]]}`), strings.NewReader(`{"b": [[2]], "a": 1}`), 0)
+	if actual := describeLimitError(err); actual != `"/b/0/0": MaxDepth` {
+		t.Errorf("expected MaxDepth error; got %v", err)
+	}
+}
+
+func TestJSONDiffer_CompareReadersContext_limits(t *testing.T) {
+	// values that are compared in lockstep count towards the Limits like decoded values
+	type testCase struct {
+		a        string
+		b        string
+		limits   Limits
+		expected string
+	}
+	tcs := []testCase{
+		{`[[[1]]]`, `[[[1]]]`, Limits{MaxDepth: 3}, ""},
+		{`[[[1]]]`, `[[[1]]]`, Limits{MaxDepth: 2}, `"/0/0/0": MaxDepth`},
+		{`{"a": {"b": {}}}`, `{"a": {"b": {}}}`, Limits{MaxDepth: 1}, `"/a/b": MaxDepth`},
+		{`[1, 2, 3]`, `[1, 2, 3]`, Limits{MaxNodes: 4}, ""},
+		{`[1, 2, 3]`, `[1, 2, 3]`, Limits{MaxNodes: 3}, `"/2": MaxNodes`},
+		{`{"a": [1], "b": 2}`, `{"a": [1], "b": 2}`, Limits{MaxNodes: 3}, `"/b": MaxNodes`},
+		{`{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, Limits{MaxNodes: 3}, ""},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Limits: tc.limits}
+		_, err := jd.CompareReadersContext(context.Background(), strings.NewReader(tc.a), strings.NewReader(tc.b), 0)
+		if actual := describeLimitError(err); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, err)
+		}
+		// the limits must be enforced like by CompareContext
+		_, err = jd.CompareContext(context.Background(), []byte(tc.a), []byte(tc.b))
+		if actual := describeLimitError(err); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v from CompareContext; got %v", tc.a, tc.b, tc.expected, err)
+		}
+	}
+}
+
+func TestJSONDiffer_Context_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	a := `{"a": [1, 2]}`
+	_, err := jd.CompareReadersContext(ctx, strings.NewReader(a), strings.NewReader(a), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("[CompareReadersContext] expected %v; got %v", context.Canceled, err)
+	}
+	_, err = jd.CompareJSONLinesContext(ctx, strings.NewReader(a+"\n"), strings.NewReader(a+"\n"), JSONLinesOptions{}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("[CompareJSONLinesContext] expected %v; got %v", context.Canceled, err)
+	}
+	_, err = jd.CompareValueContext(ctx, map[string][]int{"a": {1, 2}}, []byte(a))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("[CompareValueContext] expected %v; got %v", context.Canceled, err)
+	}
+}
+
+// describeLimitError returns the path and the limit of a *LimitError.
+func describeLimitError(err error) string {
+	if err == nil {
+		return ""
+	}
+	le, ok := err.(*LimitError)
+	if !ok {
+		return err.Error()
+	}
+	return fmt.Sprintf("%q: %s", le.Path.Pointer(), le.Limit)
+}
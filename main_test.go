@@ -0,0 +1,12 @@
+package compare
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// tests of colored output mustn't depend on the environment (cf. TestJSONDiff_Format_noColor)
+	os.Unsetenv("NO_COLOR")
+	os.Exit(m.Run())
+}
@@ -0,0 +1,88 @@
+package compare
+
+// maximumMatching matches n left elements with m right elements, so that as
+// many left elements as possible are matched with different right elements
+// that they're equal to. Unlike matching each left element with the first
+// unmatched right element that's equal to it, this also works if equality
+// isn't transitive (e.g. because of tolerances), where an earlier element may
+// take the only partner of a later one.
+//
+// It returns the index of the right element matched with each left element,
+// or -1 for left elements that can't be matched. Unless all is true, it stops
+// at the first of them (and returns the matches up to it). Left elements are
+// matched with the first right elements that are available, so equal arrays
+// are matched index by index. equal is called at most once for each pair of
+// elements, i.e. up to n*m times.
+//
+// The matching is found with Kuhn's augmenting path algorithm.
+func maximumMatching(n, m int, all bool, equal func(i, j int) (bool, error)) ([]int, error) {
+	// edges caches the results of equal: 0 if unknown, 1 if equal, -1 otherwise
+	edges := make([][]int8, n)
+	for i := range edges {
+		edges[i] = make([]int8, m)
+	}
+	isEqual := func(i, j int) (bool, error) {
+		if edges[i][j] == 0 {
+			same, err := equal(i, j)
+			if err != nil {
+				return false, err
+			}
+			edges[i][j] = -1
+			if same {
+				edges[i][j] = 1
+			}
+		}
+		return edges[i][j] > 0, nil
+	}
+
+	left, right := make([]int, n), make([]int, m)
+	for j := range right {
+		right[j] = -1
+	}
+	// augment tries to match left element i with an unmatched right element,
+	// or else with a matched one whose left element can be matched with
+	// another right element instead.
+	var augment func(i int, visited []bool) (bool, error)
+	augment = func(i int, visited []bool) (bool, error) {
+		for _, free := range []bool{true, false} {
+			for j := 0; j < m; j++ {
+				if visited[j] || (right[j] < 0) != free {
+					continue
+				}
+				same, err := isEqual(i, j)
+				if err != nil {
+					return false, err
+				}
+				if !same {
+					continue
+				}
+				visited[j] = true
+				if !free {
+					ok, err := augment(right[j], visited)
+					if err != nil {
+						return false, err
+					}
+					if !ok {
+						continue
+					}
+				}
+				left[i], right[j] = j, i
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	for i := range left {
+		left[i] = -1
+		ok, err := augment(i, make([]bool, m))
+		if err != nil {
+			return nil, err
+		}
+		// a left element that can't be matched now can't be matched later
+		if !ok && !all {
+			return left[:i+1], nil
+		}
+	}
+	return left, nil
+}
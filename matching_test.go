@@ -0,0 +1,55 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMaximumMatching(t *testing.T) {
+	type testCase struct {
+		equal    string // rows of left elements, "1" where they're equal to right elements
+		all      bool
+		expected string
+	}
+	tcs := []testCase{
+		{"", true, "[]"},
+		{"100 010 001", true, "[0 1 2]"},
+		{"111 111 111", true, "[0 1 2]"},
+		{"110 100", true, "[1 0]"}, // greedy matching would leave the second element unmatched
+		{"011 110 100", true, "[2 1 0]"},
+		{"10 10 01", true, "[0 -1 1]"},
+		{"10 10 01", false, "[0 -1]"},
+		{"000 111", false, "[-1]"},
+		{"1 1", true, "[0 -1]"},
+		{"11", true, "[0]"},
+	}
+	for _, tc := range tcs {
+		rows := strings.Fields(tc.equal)
+		m := 0
+		if len(rows) > 0 {
+			m = len(rows[0])
+		}
+		calls := map[[2]int]int{}
+		actual, err := maximumMatching(len(rows), m, tc.all, func(i, j int) (bool, error) {
+			calls[[2]int{i, j}]++
+			return rows[i][j] == '1', nil
+		})
+		if err != nil {
+			t.Errorf("[%v] %v", tc.equal, err)
+		} else if fmt.Sprint(actual) != tc.expected {
+			t.Errorf("[%v] expected %v; got %v", tc.equal, tc.expected, actual)
+		}
+		for pair, n := range calls {
+			if n > 1 {
+				t.Errorf("[%v] compared %v %d times", tc.equal, pair, n)
+			}
+		}
+	}
+
+	expected := errors.New("oops")
+	if _, err := maximumMatching(2, 2, true, func(i, j int) (bool, error) { return i == j, expected }); err != expected {
+		t.Errorf("expected %v; got %v", expected, err)
+	}
+}
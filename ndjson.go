@@ -0,0 +1,299 @@
+package compare
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONLinesOptions specifies how the records of two JSON Lines streams are matched.
+type JSONLinesOptions struct {
+	// Key specifies a JSON Pointer (https://tools.ietf.org/html/rfc6901), e.g.
+	// "/id", identifying the key of each record. Records with equal keys are
+	// compared with each other. If Key is empty, records are compared by
+	// position, i.e. the first record on the left with the first record on the
+	// right, and so on.
+	Key string
+}
+
+// A RecordDiff describes a record of two JSON Lines streams that differs.
+type RecordDiff struct {
+	// LeftLine is the line number of the record on the left side (0 if it's missing).
+	LeftLine int
+	// RightLine is the line number of the record on the right side (0 if it's missing).
+	RightLine int
+	// Key is the JSON encoding of the key of the record (empty if records
+	// are compared by position).
+	Key string
+	// Diff contains the differences between the records (nil if the record
+	// only exists on one side).
+	Diff *JSONDiff
+}
+
+// Missing returns true if the record only exists on the left side.
+func (rd RecordDiff) Missing() bool {
+	return rd.RightLine == 0
+}
+
+// Extra returns true if the record only exists on the right side.
+func (rd RecordDiff) Extra() bool {
+	return rd.LeftLine == 0
+}
+
+// JSONLinesStats contains the number of records by outcome of the comparison.
+type JSONLinesStats struct {
+	// Equal is the number of records that are considered equal.
+	Equal int
+	// Different is the number of records that exist on both sides, but differ.
+	Different int
+	// Missing is the number of records that only exist on the left side.
+	Missing int
+	// Extra is the number of records that only exist on the right side.
+	Extra int
+}
+
+// CompareJSONLines compares two streams of newline-delimited JSON values
+// (http://jsonlines.org/) record by record. It's equivalent to
+// CompareJSONLinesContext(context.Background(), left, right, opts, fn).
+func (jd JSONDiffer) CompareJSONLines(left, right io.Reader, opts JSONLinesOptions, fn func(RecordDiff) error) (JSONLinesStats, error) {
+	return jd.CompareJSONLinesContext(context.Background(), left, right, opts, fn)
+}
+
+// CompareJSONLinesContext compares two streams of newline-delimited JSON values
+// (http://jsonlines.org/) record by record. Blank lines are ignored. For each
+// record that differs (or only exists on one side), fn is called. If fn
+// returns an error, the comparison stops and the error is returned.
+//
+// The streams are read line by line, in lockstep. When records are matched by
+// key, records whose keys haven't been found on the other side yet are kept in
+// memory until they are, so memory usage is only bounded if records with
+// equal keys are in similar positions (e.g. if both streams are sorted by key).
+// In addition, the keys of all records are kept in memory to detect duplicates.
+// Records that are still unmatched at the end of the streams are reported in
+// the order of their keys' JSON encodings.
+//
+// Returns an error if a record doesn't adhere to the JSON syntax, if a record
+// doesn't contain the key, if a key occurs twice in the same stream, if a
+// stream couldn't be read, or a *LimitError if the comparison of a record
+// pair exceeds one of the Limits or ctx is done before the comparison is
+// finished.
+func (jd JSONDiffer) CompareJSONLinesContext(ctx context.Context, left, right io.Reader, opts JSONLinesOptions, fn func(RecordDiff) error) (JSONLinesStats, error) {
+	c := &linesComparison{
+		JSONDiffer: jd,
+		ctx:        ctx,
+		opts:       opts,
+		fn:         fn,
+		left:       newRecordReader(left),
+		right:      newRecordReader(right),
+		pending:    [2]map[string]*record{{}, {}},
+		seen:       [2]map[string]bool{{}, {}},
+	}
+	err := c.run()
+	return c.stats, err
+}
+
+// linesComparison holds the state of a single comparison of two JSON Lines streams.
+type linesComparison struct {
+	JSONDiffer
+	ctx         context.Context
+	opts        JSONLinesOptions
+	fn          func(RecordDiff) error
+	left, right *recordReader
+	// pending contains the unmatched records of the left and the right side by key.
+	pending [2]map[string]*record
+	// seen contains the keys of all records of the left and the right side.
+	seen  [2]map[string]bool
+	stats JSONLinesStats
+}
+
+func (c *linesComparison) run() error {
+	for {
+		if err := c.ctx.Err(); err != nil {
+			return &LimitError{Limit: LimitContext, Err: err}
+		}
+		l, err := c.left.next()
+		if err != nil {
+			return err
+		}
+		r, err := c.right.next()
+		if err != nil {
+			return err
+		}
+		if l == nil && r == nil {
+			break
+		}
+		if c.opts.Key == "" {
+			if err := c.compare(l, r, ""); err != nil {
+				return err
+			}
+			continue
+		}
+		for side, rec := range []*record{l, r} {
+			if rec == nil {
+				continue
+			}
+			if err := c.match(side, rec); err != nil {
+				return err
+			}
+		}
+	}
+
+	// report unmatched records
+	for side := range c.pending {
+		keys := make([]string, 0, len(c.pending[side]))
+		for k := range c.pending[side] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			var err error
+			if side == 0 {
+				err = c.compare(c.pending[side][k], nil, k)
+			} else {
+				err = c.compare(nil, c.pending[side][k], k)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// match compares a record with the record with the same key on the other
+// side, if it has already been read. Otherwise, the record is remembered.
+func (c *linesComparison) match(side int, rec *record) error {
+	v, ok := lookupPointer(rec.value, c.opts.Key)
+	if !ok {
+		return fmt.Errorf("line %d: key %s not found", rec.line, c.opts.Key)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	key := string(b)
+
+	if c.seen[side][key] {
+		return fmt.Errorf("line %d: duplicate key %s", rec.line, key)
+	}
+	c.seen[side][key] = true
+	other, ok := c.pending[1-side][key]
+	if !ok {
+		c.pending[side][key] = rec
+		return nil
+	}
+	delete(c.pending[1-side], key)
+	if side == 0 {
+		return c.compare(rec, other, key)
+	}
+	return c.compare(other, rec, key)
+}
+
+// compare compares two records (either of which may be nil), updates the
+// statistics and calls fn if they differ.
+func (c *linesComparison) compare(l, r *record, key string) error {
+	rd := RecordDiff{Key: key}
+	switch {
+	case r == nil:
+		c.stats.Missing++
+		rd.LeftLine = l.line
+	case l == nil:
+		c.stats.Extra++
+		rd.RightLine = r.line
+	default:
+		rd.LeftLine, rd.RightLine = l.line, r.line
+		var err error
+		if rd.Diff, err = c.compareDecoded(c.ctx, l.value, r.value); err != nil {
+			return err
+		}
+		if !rd.Diff.Modified() {
+			c.stats.Equal++
+			return nil
+		}
+		c.stats.Different++
+	}
+	if c.fn == nil {
+		return nil
+	}
+	return c.fn(rd)
+}
+
+// record is a decoded JSON value read from a line.
+type record struct {
+	line  int
+	value interface{}
+}
+
+// recordReader reads records from a JSON Lines stream.
+type recordReader struct {
+	r    *bufio.Reader
+	line int
+	eof  bool
+}
+
+func newRecordReader(r io.Reader) *recordReader {
+	return &recordReader{r: bufio.NewReader(r)}
+}
+
+// next returns the next record, or nil at the end of the stream.
+func (rr *recordReader) next() (*record, error) {
+	for !rr.eof {
+		// unlike bufio.Scanner, ReadBytes doesn't limit the length of lines
+		b, err := rr.r.ReadBytes('\n')
+		if err == io.EOF {
+			rr.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 && rr.eof {
+			break
+		}
+		rr.line++
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		rec := &record{line: rr.line}
+		if err := json.Unmarshal(b, &rec.value); err != nil {
+			return nil, fmt.Errorf("line %d: %v", rr.line, err)
+		}
+		return rec, nil
+	}
+	return nil, nil
+}
+
+// lookupPointer returns the value identified by a JSON Pointer.
+func lookupPointer(v interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return v, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = pointerUnescaper.Replace(token)
+		switch c := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = c[token]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			v = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
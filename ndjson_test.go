@@ -0,0 +1,175 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func ExampleJSONDiffer_CompareJSONLines() {
+	left := `{"id": 1, "name": "foo"}
+{"id": 2, "name": "bar"}
+{"id": 3, "name": "baz"}
+`
+	right := `{"id": 2, "name": "bar"}
+{"id": 1, "name": "FOO"}
+{"id": 4, "name": "qux"}
+`
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	stats, err := jd.CompareJSONLines(strings.NewReader(left), strings.NewReader(right), JSONLinesOptions{Key: "/id"},
+		func(rd RecordDiff) error {
+			switch {
+			case rd.Missing():
+				fmt.Printf("%s: missing (line %d)\n", rd.Key, rd.LeftLine)
+			case rd.Extra():
+				fmt.Printf("%s: extra (line %d)\n", rd.Key, rd.RightLine)
+			default:
+				fmt.Printf("%s: %s\n", rd.Key, describeDifferences(rd.Diff.Differences()))
+			}
+			return nil
+		})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%+v\n", stats)
+	// Output:
+	// 1: [/name replace foo FOO]
+	// 3: missing (line 3)
+	// 4: extra (line 3)
+	// {Equal:1 Different:1 Missing:1 Extra:1}
+}
+
+func TestJSONDiffer_CompareJSONLines(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		key      string
+		expected string
+		stats    JSONLinesStats
+	}
+	tcs := []testCase{
+		{"", "", "", "", JSONLinesStats{}},
+		{"1\n2\n", "1\n2", "", "", JSONLinesStats{Equal: 2}},
+		{"1\n2\n3\n", "1\n\n4\n", "", "2:3 [ replace 2 4]; 3:0 missing", JSONLinesStats{Equal: 1, Different: 1, Missing: 1}},
+		{"[1]\n", "[1]\r\n{}\n\n", "", "0:2 extra", JSONLinesStats{Equal: 1, Extra: 1}},
+		{`{"a": 1.01}`, `{"a": 1}`, "", "", JSONLinesStats{Equal: 1}},
+		{
+			"{\"k\": \"a\", \"v\": 1}\n{\"k\": \"b\", \"v\": 2}\n",
+			"{\"k\": \"b\", \"v\": 3}\n{\"k\": \"a\", \"v\": 1}\n",
+			"/k",
+			`2:1 "b" [/v replace 2 3]`,
+			JSONLinesStats{Equal: 1, Different: 1},
+		},
+		{
+			"{\"k\": [1, {\"a/b\": 2}]}\n{\"k\": [1, {\"a/b\": 3}]}\n",
+			"{\"k\": [0, {\"a/b\": 3}]}\n{\"k\": [0, {\"a/b\": 2}]}\n",
+			"/k/1/a~1b",
+			"2:1 3 [/k/0 replace 1 0]; 1:2 2 [/k/0 replace 1 0]",
+			JSONLinesStats{Different: 2},
+		},
+		{
+			"{\"k\": 2}\n{\"k\": 1}\n",
+			"{\"k\": 3}\n",
+			"/k",
+			"2:0 1 missing; 1:0 2 missing; 0:1 3 extra",
+			JSONLinesStats{Missing: 2, Extra: 1},
+		},
+		{"{\"k\": null}\n", "{\"k\": null, \"v\": 1}\n", "/k", "1:1 null [/v add <nil> 1]", JSONLinesStats{Different: 1}},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	for _, tc := range tcs {
+		var rds []string
+		stats, err := jd.CompareJSONLines(strings.NewReader(tc.a), strings.NewReader(tc.b), JSONLinesOptions{Key: tc.key},
+			func(rd RecordDiff) error {
+				rds = append(rds, describeRecordDiff(rd))
+				return nil
+			})
+		if err != nil {
+			t.Errorf("[%q == %q] %v", tc.a, tc.b, err)
+			continue
+		}
+		if actual := strings.Join(rds, "; "); actual != tc.expected {
+			t.Errorf("[%q == %q] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+		if stats != tc.stats {
+			t.Errorf("[%q == %q] expected %+v; got %+v", tc.a, tc.b, tc.stats, stats)
+		}
+	}
+}
+
+func TestJSONDiffer_CompareJSONLines_stop(t *testing.T) {
+	// the comparison must stop before reading the broken part of the input
+	broken := io.MultiReader(strings.NewReader("1\n2\n3\n"), &failingReader{errors.New("broken")})
+	right := strings.NewReader("1\n5\n6\n7\n")
+	stop := errors.New("stop")
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	stats, err := jd.CompareJSONLines(broken, right, JSONLinesOptions{}, func(rd RecordDiff) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("expected %v; got %v", stop, err)
+	}
+	if expected := (JSONLinesStats{Equal: 1, Different: 1}); stats != expected {
+		t.Errorf("expected %+v; got %+v", expected, stats)
+	}
+}
+
+func TestJSONDiffer_CompareJSONLines_longLines(t *testing.T) {
+	long := `{"a": "` + strings.Repeat("x", 100000) + `"}` + "\n"
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	stats, err := jd.CompareJSONLines(strings.NewReader(long+long), strings.NewReader(long+long), JSONLinesOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (JSONLinesStats{Equal: 2}); stats != expected {
+		t.Errorf("expected %+v; got %+v", expected, stats)
+	}
+}
+
+func TestJSONDiffer_CompareJSONLines_errors(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		key      string
+		expected string
+	}
+	tcs := []testCase{
+		{"1\n{\"a\": 1\n", "1\n1\n", "", "line 2: unexpected end of JSON input"},
+		{"1\n", "\n1 2\n", "", "line 2: invalid character '2' after top-level value"},
+		{"{\"k\": 1}\n", "{\"j\": 1}\n", "/k", "line 1: key /k not found"},
+		{"{\"k\": [1]}\n", "{\"k\": [1]}\n", "/k/1", "line 1: key /k/1 not found"},
+		{"{\"k\": 1}\n", "{\"k\": 1}\n", "k", "line 1: key k not found"},
+		{"{\"k\": 1}\n{\"k\": 1.0}\n", "{\"k\": 2}\n", "/k", "line 2: duplicate key 1"},
+		// the first record was already matched when the duplicate is read
+		{"{\"k\": 1}\n{\"k\": 1}\n", "{\"k\": 1}\n", "/k", "line 2: duplicate key 1"},
+		{"{\"k\": 1}\n", "{\"k\": 1}\n{\"k\": 2}\n{\"k\": 1}\n", "/k", "line 3: duplicate key 1"},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		_, err := jd.CompareJSONLines(strings.NewReader(tc.a), strings.NewReader(tc.b), JSONLinesOptions{Key: tc.key}, nil)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("[%q == %q] expected %v; got %v", tc.a, tc.b, tc.expected, err)
+		}
+	}
+	if _, err := jd.CompareJSONLines(strings.NewReader("1\n"), &failingReader{io.ErrUnexpectedEOF}, JSONLinesOptions{}, nil); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v; got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+// describeRecordDiff returns a description of a RecordDiff.
+func describeRecordDiff(rd RecordDiff) string {
+	s := fmt.Sprintf("%d:%d", rd.LeftLine, rd.RightLine)
+	if rd.Key != "" {
+		s += " " + rd.Key
+	}
+	switch {
+	case rd.Missing():
+		return s + " missing"
+	case rd.Extra():
+		return s + " extra"
+	}
+	return s + " " + describeDifferences(rd.Diff.Differences())
+}
@@ -0,0 +1,76 @@
+package compare
+
+import (
+	"sync"
+)
+
+// defaultParallelThreshold is the threshold that's used if Parallelism.Threshold is 0.
+const defaultParallelThreshold = 64
+
+// Parallelism specifies whether and how the elements of large values (objects
+// and arrays, or maps, slices, arrays and structs) are compared concurrently.
+// The results are the same as if the elements were compared sequentially.
+type Parallelism struct {
+	// Workers is the maximum number of goroutines a comparison starts in
+	// addition to the calling goroutine. If it's 0, elements are compared
+	// sequentially.
+	Workers int
+	// Threshold is the minimum number of elements a value must have for its
+	// elements to be compared concurrently. If it's 0, a threshold of 64 is used.
+	Threshold int
+}
+
+// workerPool bounds the number of goroutines started by a single comparison.
+// A nil workerPool doesn't start any goroutines.
+type workerPool chan struct{}
+
+func newWorkerPool(p Parallelism) workerPool {
+	if p.Workers <= 0 {
+		return nil
+	}
+	return make(workerPool, p.Workers)
+}
+
+// chunks returns the number of chunks the elements of a value with n elements
+// should be split into. It's 1 if they should be compared sequentially.
+func (p workerPool) chunks(threshold, n int) int {
+	if threshold <= 0 {
+		threshold = defaultParallelThreshold
+	}
+	if p == nil || n < threshold || n < 2 {
+		return 1
+	}
+	if c := cap(p) + 1; c < n {
+		return c
+	}
+	return n
+}
+
+// split splits n elements into the given number of chunks, and calls fn with
+// the index and the range of elements of each chunk. As long as the pool has
+// capacity, chunks are processed by separate goroutines; the remaining chunks
+// (including the last one) are processed by the calling goroutine.
+// split returns when all chunks have been processed.
+func (p workerPool) split(n, chunks int, fn func(chunk, start, end int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < chunks; i++ {
+		start, end := i*n/chunks, (i+1)*n/chunks
+		if i < chunks-1 {
+			select {
+			case p <- struct{}{}:
+				wg.Add(1)
+				go func(i, start, end int) {
+					defer func() {
+						<-p
+						wg.Done()
+					}()
+					fn(i, start, end)
+				}(i, start, end)
+				continue
+			default:
+			}
+		}
+		fn(i, start, end)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,226 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWorkerPool_chunks(t *testing.T) {
+	type testCase struct {
+		workers   int
+		threshold int
+		n         int
+		expected  int
+	}
+	tcs := []testCase{
+		{0, 0, 1000, 1},
+		{0, 1, 1000, 1},
+		{3, 0, 63, 1},
+		{3, 0, 64, 4},
+		{3, 10, 9, 1},
+		{3, 2, 2, 2},
+		{3, 1, 1, 1},
+		{8, 1, 5, 5},
+	}
+	for _, tc := range tcs {
+		p := newWorkerPool(Parallelism{Workers: tc.workers})
+		if actual := p.chunks(tc.threshold, tc.n); actual != tc.expected {
+			t.Errorf("[%v, %v, %v] expected %v; got %v", tc.workers, tc.threshold, tc.n, tc.expected, actual)
+		}
+	}
+}
+
+func TestWorkerPool_split(t *testing.T) {
+	p := newWorkerPool(Parallelism{Workers: 2})
+	for n := 1; n < 20; n++ {
+		for chunks := 1; chunks <= n; chunks++ {
+			covered := make([]int, n)
+			p.split(n, chunks, func(chunk, start, end int) {
+				for i := start; i < end; i++ {
+					covered[i]++
+				}
+			})
+			for i, c := range covered {
+				if c != 1 {
+					t.Errorf("[%v, %v] element %v processed %v times", n, chunks, i, c)
+				}
+			}
+		}
+	}
+}
+
+// parallelTestValue returns a large value with differences at various levels.
+func parallelTestValue(variant int) map[string]interface{} {
+	items := make([]interface{}, 300)
+	for i := range items {
+		item := map[string]interface{}{
+			"id":    float64(i),
+			"name":  fmt.Sprintf("item %d", i),
+			"price": float64(i) + 0.5,
+			"tags":  []interface{}{"a", "b", fmt.Sprint(i % 7)},
+		}
+		if i%(10+variant) == 0 {
+			item["price"] = float64(i) + 0.55 // tolerated
+		}
+		if i%(13+variant) == 0 {
+			item["name"] = fmt.Sprintf("ITEM %d", i)
+		}
+		if i%(17+variant) == 0 {
+			delete(item, "tags")
+		}
+		items[i] = item
+	}
+	groups := map[string]interface{}{}
+	for i := 0; i < 100; i++ {
+		if (i+variant)%11 != 0 {
+			groups[fmt.Sprintf("g%02d", i)] = []interface{}{float64(i), float64(i * variant)}
+		}
+	}
+	return map[string]interface{}{"items": items[:300-variant], "groups": groups}
+}
+
+func TestJSONDiffer_Compare_parallel(t *testing.T) {
+	left, err := json.Marshal(parallelTestValue(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := json.Marshal(parallelTestValue(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sequential := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}, TextDiffMinimumLength: 5}
+	expected, err := sequential.Compare(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expected.Modified() || len(expected.Tolerances()) == 0 {
+		t.Fatal("expected differences and tolerances")
+	}
+	expectedText, err := expected.Format(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []Parallelism{{Workers: 1}, {Workers: 4, Threshold: 2}, {Workers: 16, Threshold: 1}} {
+		jd := sequential
+		jd.Parallelism = p
+		actual, err := jd.Compare(left, right)
+		if err != nil {
+			t.Errorf("[%+v] %v", p, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual.Differences(), expected.Differences()) {
+			t.Errorf("[%+v] expected %v; got %v", p, describeDifferences(expected.Differences()), describeDifferences(actual.Differences()))
+		}
+		if !reflect.DeepEqual(actual.Tolerances(), expected.Tolerances()) {
+			t.Errorf("[%+v] expected %v; got %v", p, expected.Tolerances(), actual.Tolerances())
+		}
+		if actualText, err := actual.Format(false); err != nil || actualText != expectedText {
+			t.Errorf("[%+v] expected %v; got %v (%v)", p, expectedText, actualText, err)
+		}
+	}
+}
+
+func TestJSONDiffer_CompareContext_parallelLimits(t *testing.T) {
+	left, err := json.Marshal(parallelTestValue(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := json.Marshal(parallelTestValue(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, limits := range []Limits{{MaxNodes: 1000}, {MaxDifferences: 20}} {
+		jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Limits: limits, Parallelism: Parallelism{Workers: 4, Threshold: 2}}
+		if _, err := jd.Compare(left, right); err == nil {
+			t.Errorf("[%+v] expected error", limits)
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_parallel(t *testing.T) {
+	type item struct {
+		ID    int
+		Name  string
+		Price float64
+		Tags  []string
+	}
+	type catalog struct {
+		Items  []item
+		Groups map[string][2]int
+	}
+	newCatalog := func(variant int) catalog {
+		c := catalog{Groups: map[string][2]int{}}
+		for i := 0; i < 200; i++ {
+			it := item{ID: i, Name: fmt.Sprintf("item %d", i), Price: float64(i) + 0.5, Tags: []string{"a", fmt.Sprint(i % 3)}}
+			if i%(10+variant) == 0 {
+				it.Price += 0.05
+			}
+			if i%(13+variant) == 0 {
+				it.Tags = it.Tags[:1]
+			}
+			c.Items = append(c.Items, it)
+		}
+		for i := 0; i < 100; i++ {
+			if (i+variant)%11 != 0 {
+				c.Groups[fmt.Sprintf("g%02d", i)] = [2]int{i, i * variant}
+			}
+		}
+		return c
+	}
+	a, b := newCatalog(0), newCatalog(1)
+
+	sequential := DeepEqualer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	expected, err := sequential.Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expected.Modified() || len(expected.Tolerances()) == 0 {
+		t.Fatal("expected differences and tolerances")
+	}
+
+	for _, p := range []Parallelism{{Workers: 1}, {Workers: 4, Threshold: 2}, {Workers: 16, Threshold: 1}} {
+		e := sequential
+		e.Parallelism = p
+		actual, err := e.Compare(a, b)
+		if err != nil {
+			t.Errorf("[%+v] %v", p, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("[%+v] expected %v; got %v", p, describeDifferences(expected.Differences()), describeDifferences(actual.Differences()))
+		}
+		for _, tc := range []struct {
+			a, b     catalog
+			expected bool
+		}{{a, b, false}, {a, a, true}, {b, newCatalog(1), true}} {
+			if same, err := e.Equal(tc.a, tc.b); err != nil || same != tc.expected {
+				t.Errorf("[%+v] expected %v; got %v (%v)", p, tc.expected, same, err)
+			}
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_parallelError(t *testing.T) {
+	type s struct {
+		f func()
+	}
+	a, b := make([]s, 10), make([]s, 10)
+	a[7].f = func() {}
+
+	sequential := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	_, expected := sequential.Compare(a, b)
+	if expected == nil {
+		t.Fatal("expected error")
+	}
+	parallel := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Parallelism: Parallelism{Workers: 4, Threshold: 2}}
+	if _, err := parallel.Compare(a, b); err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v; got %v", expected, err)
+	}
+	if _, err := parallel.Equal(a, b); err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected %v; got %v", expected, err)
+	}
+}
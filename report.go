@@ -0,0 +1,124 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ReportVersion is the version of the schema of Reports. It's incremented
+// whenever the schema changes in a way that isn't backwards compatible.
+const ReportVersion = 1
+
+// A Report is a machine-readable description of the differences between two
+// values, intended to be serialized as JSON. For example:
+//
+//	{
+//	  "version": 1,
+//	  "summary": {"added": 1, "removed": 0, "replaced": 1, "tolerated": 1},
+//	  "changes": [
+//	    {"path": "/a/0", "op": "replace", "old": 1, "new": 2, "comparator": "Float64"},
+//	    {"path": "/b", "op": "add", "new": "x"}
+//	  ],
+//	  "tolerated": [
+//	    {"path": "/c", "old": 1.5, "new": 1.55, "rule": "Float64Tolerance: 0.1"}
+//	  ]
+//	}
+//
+// Paths are JSON Pointers (https://tools.ietf.org/html/rfc6901) and operations
+// are named like in JSON Patch (https://tools.ietf.org/html/rfc6902). Changes
+// don't contain "old" for added values and "new" for removed values, and
+// "comparator" (as well as "message", if there is one) is only set for
+// replaced values. Values that can't be
+// represented as JSON are replaced by their string representations.
+type Report struct {
+	// Version is the version of the schema (see ReportVersion).
+	Version int `json:"version"`
+	// Summary contains the number of changes per operation.
+	Summary ReportSummary `json:"summary"`
+	// Changes describes the values that differ.
+	Changes []ReportChange `json:"changes"`
+	// Tolerated describes the values that aren't identical, but were considered equal.
+	Tolerated []ReportTolerance `json:"tolerated"`
+}
+
+// ReportSummary contains the number of changes per operation.
+type ReportSummary struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Replaced  int `json:"replaced"`
+	Tolerated int `json:"tolerated"`
+}
+
+// ReportChange describes a value that differs.
+type ReportChange struct {
+	Path       string          `json:"path"`
+	Operation  Operation       `json:"op"`
+	Old        json.RawMessage `json:"old,omitempty"`
+	New        json.RawMessage `json:"new,omitempty"`
+	Comparator string          `json:"comparator,omitempty"`
+	Message    string          `json:"message,omitempty"`
+}
+
+// ReportTolerance describes a value that isn't identical, but was considered equal.
+type ReportTolerance struct {
+	Path string          `json:"path"`
+	Old  json.RawMessage `json:"old"`
+	New  json.RawMessage `json:"new"`
+	Rule string          `json:"rule"`
+}
+
+// Report returns a machine-readable description of the differences between
+// two JSON values.
+func (d *JSONDiff) Report() *Report {
+	return newReport(d.Differences(), d.tolerances)
+}
+
+// Report returns a machine-readable description of the differences between
+// two values.
+func (d *DeepDiff) Report() *Report {
+	return newReport(d.differences, d.tolerances)
+}
+
+func newReport(diffs []Difference, tolerances []Tolerance) *Report {
+	r := &Report{
+		Version:   ReportVersion,
+		Changes:   make([]ReportChange, 0, len(diffs)),
+		Tolerated: make([]ReportTolerance, 0, len(tolerances)),
+	}
+	for _, d := range diffs {
+		c := ReportChange{Path: d.Path.Pointer(), Operation: d.Operation}
+		switch d.Operation {
+		case OperationAdd:
+			r.Summary.Added++
+			c.New = rawJSON(d.Right)
+		case OperationRemove:
+			r.Summary.Removed++
+			c.Old = rawJSON(d.Left)
+		default:
+			r.Summary.Replaced++
+			c.Old, c.New = rawJSON(d.Left), rawJSON(d.Right)
+			c.Comparator, c.Message = d.Comparator, d.Message
+		}
+		r.Changes = append(r.Changes, c)
+	}
+	for _, t := range tolerances {
+		r.Summary.Tolerated++
+		r.Tolerated = append(r.Tolerated, ReportTolerance{
+			Path: t.Path.Pointer(),
+			Old:  rawJSON(t.Left),
+			New:  rawJSON(t.Right),
+			Rule: t.Rule,
+		})
+	}
+	return r
+}
+
+// rawJSON returns the JSON encoding of a value, or of its string
+// representation if the value can't be encoded (e.g. because it's a channel).
+func rawJSON(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v)) // never fails
+	}
+	return b
+}
@@ -0,0 +1,149 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func ExampleJSONDiff_Report() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	d, err := jd.Compare(
+		[]byte(`{"x": 1.6, "y": [3.8, "hello"]}`),
+		[]byte(`{"x": 1.57, "y": [3.6, "hello"], "z": 0}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	b, err := json.MarshalIndent(d.Report(), "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(b))
+	// Output:
+	// {
+	//   "version": 1,
+	//   "summary": {
+	//     "added": 1,
+	//     "removed": 0,
+	//     "replaced": 1,
+	//     "tolerated": 1
+	//   },
+	//   "changes": [
+	//     {
+	//       "path": "/y/0",
+	//       "op": "replace",
+	//       "old": 3.8,
+	//       "new": 3.6,
+	//       "comparator": "Float64"
+	//     },
+	//     {
+	//       "path": "/z",
+	//       "op": "add",
+	//       "new": 0
+	//     }
+	//   ],
+	//   "tolerated": [
+	//     {
+	//       "path": "/x",
+	//       "old": 1.6,
+	//       "new": 1.57,
+	//       "rule": "Float64Tolerance: 0.1"
+	//     }
+	//   ]
+	// }
+}
+
+func TestReport(t *testing.T) {
+	type testCase struct {
+		left     string
+		right    string
+		expected string
+	}
+
+	tcs := []testCase{
+		{
+			left:     `{"a": 1}`,
+			right:    `{"a": 1}`,
+			expected: `{"version":1,"summary":{"added":0,"removed":0,"replaced":0,"tolerated":0},"changes":[],"tolerated":[]}`,
+		},
+		{
+			left:  `{"a/b": null, "c": [{"d": 1}], "e": "x"}`,
+			right: `{"a/b": 0, "c": [], "e": null}`,
+			expected: `{"version":1,"summary":{"added":0,"removed":1,"replaced":2,"tolerated":0},"changes":[` +
+				`{"path":"/a~1b","op":"replace","old":null,"new":0,"comparator":"type"},` +
+				`{"path":"/c/0","op":"remove","old":{"d":1}},` +
+				`{"path":"/e","op":"replace","old":"x","new":null,"comparator":"type"}` +
+				`],"tolerated":[]}`,
+		},
+	}
+
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.left), []byte(tc.right))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(d.Report())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := string(b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.left, tc.right, tc.expected, actual)
+		}
+	}
+}
+
+func TestDeepDiff_Report(t *testing.T) {
+	type T struct {
+		S  []string
+		F  float64
+		Fn func()
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.5}}
+	d, err := e.Compare(
+		T{S: []string{"a"}, F: 1},
+		T{S: []string{"a", "b"}, F: 1.2, Fn: func() {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(d.Report())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// functions can't be represented as JSON, so their string representations are used
+	fn := fmt.Sprint(d.Differences()[1].Right)
+	expected := `{"version":1,"summary":{"added":1,"removed":0,"replaced":1,"tolerated":1},"changes":[` +
+		`{"path":"/S/1","op":"add","new":"b"},` +
+		`{"path":"/Fn","op":"replace","old":"\u003cnil\u003e","new":"` + fn + `","comparator":"reflect.DeepEqual"}` +
+		`],"tolerated":[{"path":"/F","old":1,"new":1.2,"rule":"Float64Tolerance: 0.5"}]}`
+	if actual := string(b); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestDeepDiff_Report_message(t *testing.T) {
+	e := DeepEqualer{
+		BasicEqualer: TolerantBasicEqualer{},
+		Comparators: map[reflect.Type]Comparator{
+			reflect.TypeOf(money{}): func(a, b interface{}) ComparatorResult {
+				return ComparatorResult{Message: "amounts differ"}
+			},
+		},
+	}
+	d, err := e.Compare([]money{{1, "EUR"}}, []money{{2, "EUR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(d.Report().Changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"path":"/0","op":"replace","old":{"Cents":1,"Currency":"EUR"},"new":{"Cents":2,"Currency":"EUR"},` +
+		`"comparator":"Comparators[compare.money]","message":"amounts differ"}]`
+	if actual := string(b); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
@@ -0,0 +1,195 @@
+package compare
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultTerminalWidth is the width that's assumed if standard output isn't a
+// terminal and the environment variable COLUMNS isn't set.
+const defaultTerminalWidth = 80
+
+// columnSeparator separates the left and the right column.
+const columnSeparator = " | "
+
+// sideBySideFormatter formats a diff tree as text in two columns: the left
+// value in the left column and the right value in the right column. Values at
+// the same path are on the same rows, and if a value only exists on one side
+// (or takes up more lines on one side), the other column is left blank.
+type sideBySideFormatter struct {
+	*textFormatter
+	rows []sideBySideRow
+}
+
+// sideBySideRow is a row of text in two columns. Cells are nil if a column is
+// blank.
+type sideBySideRow struct {
+	left, right *sideBySideCell
+}
+
+type sideBySideCell struct {
+	marker string
+	textLine
+}
+
+func (f *sideBySideFormatter) format(root *diffNode) string {
+	if f.opts.ChangesOnly && root.kind == nodeSame {
+		f.same(root, 0, false, false, f.opts.Context)
+	} else {
+		f.node(root, 0, false, false)
+	}
+
+	width := f.opts.MaxWidth
+	if width <= 0 {
+		width = terminalWidth()
+	}
+	columnWidth := (width - len(columnSeparator)) / 2
+	if columnWidth < 1 {
+		columnWidth = 1
+	}
+
+	var buf bytes.Buffer
+	for _, row := range f.rows {
+		line := f.cell(row.left, columnWidth, true) + columnSeparator + f.cell(row.right, columnWidth, false)
+		buf.WriteString(strings.TrimRight(line, " "))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// node formats a node. leftComma and rightComma specify whether the node is
+// followed by other elements of its parent on the left and the right side.
+func (f *sideBySideFormatter) node(n *diffNode, depth int, leftComma, rightComma bool) {
+	switch n.kind {
+	case nodeSame:
+		f.pair(markerSame, f.valueLines(n, n.left, depth, leftComma, -1),
+			markerSame, f.valueLines(n, n.right, depth, rightComma, -1))
+	case nodeAdded:
+		f.pair("", nil, markerAdded, f.valueLines(n, n.right, depth, rightComma, -1))
+	case nodeDeleted:
+		f.pair(markerDeleted, f.valueLines(n, n.left, depth, leftComma, -1), "", nil)
+	case nodeChanged:
+		f.pair(markerSame, []textLine{{depth, f.key(n, n.left) + openText(n.left)}},
+			markerSame, []textLine{{depth, f.key(n, n.right) + openText(n.right)}})
+		f.children(n, depth)
+		f.pair(markerSame, []textLine{{depth, closeText(n.left) + commaText(leftComma)}},
+			markerSame, []textLine{{depth, closeText(n.right) + commaText(rightComma)}})
+	case nodeModified:
+		if n.textDiff {
+			left, right := f.textDiffTexts(n)
+			f.pair(markerDeleted, []textLine{{depth, left + commaText(leftComma)}},
+				markerAdded, []textLine{{depth, right + commaText(rightComma)}})
+			return
+		}
+		f.pair(markerDeleted, f.valueLines(n, n.left, depth, leftComma, -1),
+			markerAdded, f.valueLines(n, n.right, depth, rightComma, -1))
+	}
+}
+
+// children formats the elements of a changed object or array (cf. textFormatter.children()).
+func (f *sideBySideFormatter) children(n *diffNode, depth int) {
+	omitted := 0
+	for i, c := range n.children {
+		leftComma, rightComma := hasLeftSibling(n.children[i+1:]), hasRightSibling(n.children[i+1:])
+		if !f.opts.ChangesOnly || c.kind != nodeSame {
+			f.omitted(n.left, omitted, depth+1)
+			omitted = 0
+			f.node(c, depth+1, leftComma, rightComma)
+			continue
+		}
+		if f.opts.Context > 0 {
+			f.same(c, depth+1, leftComma, rightComma, f.opts.Context-1)
+			continue
+		}
+		omitted++
+	}
+	f.omitted(n.left, omitted, depth+1)
+}
+
+// same formats an unchanged node, showing the given number of levels of its elements.
+func (f *sideBySideFormatter) same(n *diffNode, depth int, leftComma, rightComma bool, levels int) {
+	f.pair(markerSame, f.valueLines(n, n.left, depth, leftComma, levels),
+		markerSame, f.valueLines(n, n.right, depth, rightComma, levels))
+}
+
+// omitted formats a row replacing a number of omitted elements of an object or array.
+func (f *sideBySideFormatter) omitted(container interface{}, count, depth int) {
+	if count > 0 {
+		text := omittedText(container, count)
+		f.pair(markerSame, []textLine{{depth, text}}, markerSame, []textLine{{depth, text}})
+	}
+}
+
+// pair adds rows with the given lines in the left and the right column. If
+// one side has fewer lines than the other, its column is padded with blank cells.
+func (f *sideBySideFormatter) pair(leftMarker string, left []textLine, rightMarker string, right []textLine) {
+	left, right = splitAll(left), splitAll(right)
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var row sideBySideRow
+		if i < len(left) {
+			row.left = &sideBySideCell{leftMarker, left[i]}
+		}
+		if i < len(right) {
+			row.right = &sideBySideCell{rightMarker, right[i]}
+		}
+		f.rows = append(f.rows, row)
+	}
+}
+
+// cell renders a cell, truncating it to the given width. If pad is true, the
+// cell is padded with spaces, so the next column is aligned.
+func (f *sideBySideFormatter) cell(c *sideBySideCell, width int, pad bool) string {
+	if c == nil {
+		if pad {
+			return strings.Repeat(" ", width)
+		}
+		return ""
+	}
+	s := f.truncate(c.marker+strings.Repeat("  ", c.depth)+c.text, width)
+	if pad {
+		s += strings.Repeat(" ", width-displayWidth(stripANSI(s)))
+	}
+	return f.colorize(c.marker, s)
+}
+
+// splitAll splits the texts of lines at line breaks (cf. splitLines()).
+func splitAll(lines []textLine) []textLine {
+	var split []textLine
+	for _, l := range lines {
+		split = append(split, splitLines(l.depth, l.text)...)
+	}
+	return split
+}
+
+// hasRightSibling returns true if any of the nodes has a value on the right side.
+func hasRightSibling(ns []*diffNode) bool {
+	for _, n := range ns {
+		if n.kind != nodeDeleted {
+			return true
+		}
+	}
+	return false
+}
+
+// terminalWidth returns the width of the terminal that standard output is
+// connected to.
+func terminalWidth() int {
+	return terminalWidthOf(int(os.Stdout.Fd()))
+}
+
+// terminalWidthOf returns the width of the terminal with the given file
+// descriptor. If it isn't a terminal, the width is specified by the
+// environment variable COLUMNS.
+func terminalWidthOf(fd int) int {
+	if w, _, err := term.GetSize(fd); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultTerminalWidth
+}
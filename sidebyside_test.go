@@ -0,0 +1,112 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func ExampleJSONDiff_FormatWithOptions_sideBySide() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.Compare(
+		[]byte(`{"name": "api", "ports": [80, 443], "tls": {"cert": "a.pem"}}`),
+		[]byte(`{"name": "api", "ports": [8080, 443, 8443], "debug": true}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	diff, err := d.FormatWithOptions(FormatOptions{SideBySide: true, MaxWidth: 51})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(diff)
+	// Output:
+	//  {                       |  {
+	//    "name": "api",        |    "name": "api",
+	//    "ports": [            |    "ports": [
+	// -    0: 80,              | +    0: 8080,
+	//      1: 443              |      1: 443,
+	//                          | +    2: 8443
+	//    ],                    |    ],
+	// -  "tls": {              |
+	// -    "cert": "a.pem"     |
+	// -  }                     |
+	//                          | +  "debug": true
+	//  }                       |  }
+}
+
+func TestJSONDiff_FormatWithOptions_sideBySide(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		opts     FormatOptions
+		expected string
+	}
+	tcs := []testCase{
+		{
+			`1`,
+			`{"a": 1}`,
+			FormatOptions{SideBySide: true, MaxWidth: 23},
+			"- 1        | +{\n           | +  \"a\": 1\n           | +}\n",
+		},
+		{
+			`[[1, 2], 3]`,
+			`[[1, 2], 4]`,
+			FormatOptions{SideBySide: true, MaxWidth: 23, ChangesOnly: true},
+			" [         |  [\n   ... 1 … |    ... 1 …\n-  1: 3    | +  1: 4\n ]         |  ]\n",
+		},
+		{
+			`{"text": "a\nb"}`,
+			`{"text": "a"}`,
+			FormatOptions{SideBySide: true, MaxWidth: 35},
+			" {               |  {\n-  \"text\": \"a    | +  \"text\": \"a\"\n-    b\"          |\n }               |  }\n",
+		},
+		{
+			`{"a": 1}`,
+			`{"a": 2}`,
+			FormatOptions{SideBySide: true, MaxWidth: 23, Coloring: true},
+			" {         |  {\n\x1b[30;41m-  \"a\": 1 \x1b[0m | \x1b[30;42m+  \"a\": 2\x1b[0m\n }         |  }\n",
+		},
+		{
+			`{"a": "日本"}`,
+			`{"a": "中文字"}`,
+			FormatOptions{SideBySide: true, MaxWidth: 31},
+			" {             |  {\n-  \"a\": \"日本\" | +  \"a\": \"中文…\n }             |  }\n",
+		},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := d.FormatWithOptions(tc.opts)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %q; got %q", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+	type testCase struct {
+		columns  string
+		expected int
+	}
+	tcs := []testCase{
+		{"", defaultTerminalWidth},
+		{"120", 120},
+		{"-1", defaultTerminalWidth},
+		{"wide", defaultTerminalWidth},
+	}
+	for _, tc := range tcs {
+		os.Setenv("COLUMNS", tc.columns)
+		// an invalid file descriptor isn't a terminal
+		if actual := terminalWidthOf(-1); actual != tc.expected {
+			t.Errorf("[%q] expected %v; got %v", tc.columns, tc.expected, actual)
+		}
+	}
+}
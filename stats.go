@@ -0,0 +1,136 @@
+package compare
+
+import (
+	"math"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// Stats summarizes the differences between two JSON values. Leaves are basic
+// values (null, booleans, numbers and strings) as well as empty objects and arrays.
+type Stats struct {
+	// Added is the number of leaves that only exist on the right side.
+	Added int
+	// Deleted is the number of leaves that only exist on the left side.
+	Deleted int
+	// Modified is the number of values that were replaced by other values.
+	Modified int
+	// Unchanged is the number of leaves that are considered equal.
+	Unchanged int
+	// Tolerated is the number of leaves that aren't identical, but are considered equal.
+	Tolerated int
+	// AffectedKeys contains the keys (or, for arrays, the indexes) of the
+	// top-level elements containing differences, in the order in which they're formatted.
+	AffectedKeys []string
+	// MaxDepth is the depth of the most deeply nested difference, e.g. 2 for
+	// a difference at "/a/b". It's 0 if there are no differences, or if the
+	// values themselves differ.
+	MaxDepth int
+	// Similarity is a score between 0 (completely different) and 1 (equal).
+	// It's the average similarity of all leaves, weighted by the number of
+	// leaves on either side. Added and deleted leaves have a similarity of 0,
+	// and modified values are rated by gojsondiff's Delta.Similarity().
+	Similarity float64
+}
+
+// Stats returns statistics about the differences between two JSON values.
+// They're only computed once, no matter how often Stats is called.
+func (d *JSONDiff) Stats() Stats {
+	d.statsOnce.Do(func() {
+		d.stats = newStats(newDiffTree(d))
+	})
+	s := d.stats
+	s.AffectedKeys = append([]string(nil), s.AffectedKeys...)
+	return s
+}
+
+func newStats(root *diffNode) Stats {
+	var s Stats
+	s.Similarity = s.count(root)
+	for _, c := range root.children {
+		if c.kind != nodeSame {
+			s.AffectedKeys = append(s.AffectedKeys, c.position().String())
+		}
+	}
+	return s
+}
+
+// count adds the leaves of a node to the statistics, and returns the
+// similarity of the node.
+func (s *Stats) count(n *diffNode) float64 {
+	switch n.kind {
+	case nodeSame:
+		if n.tolerance == nil && len(n.children) > 0 {
+			// tolerances may have been applied further down
+			for _, c := range n.children {
+				s.count(c)
+			}
+			return 1
+		}
+		leaves := countLeaves(n.left)
+		s.Unchanged += leaves
+		if n.tolerance != nil {
+			s.Tolerated += leaves
+		}
+		return 1
+	case nodeAdded:
+		s.Added += countLeaves(n.right)
+	case nodeDeleted:
+		s.Deleted += countLeaves(n.left)
+	case nodeModified:
+		s.Modified++
+	}
+	if n.kind != nodeSame && len(n.path) > s.MaxDepth {
+		s.MaxDepth = len(n.path)
+	}
+
+	switch n.kind {
+	case nodeModified:
+		return modifiedSimilarity(n.left, n.right)
+	case nodeChanged:
+		var weighted, weights float64
+		for _, c := range n.children {
+			w := float64(max2(countLeaves(c.left), countLeaves(c.right)))
+			weighted += w * s.count(c)
+			weights += w
+		}
+		if weights == 0 {
+			return 1
+		}
+		return weighted / weights
+	}
+	return 0
+}
+
+// modifiedSimilarity returns the similarity of a value and the value that
+// replaced it. Unlike gojsondiff's implementation, it always returns a score
+// between 0 and 1, even for empty strings and numbers of different signs.
+func modifiedSimilarity(left, right interface{}) float64 {
+	sim := gojsondiff.NewModified(nil, left, right).Similarity()
+	if math.IsNaN(sim) {
+		// empty strings, cf. https://github.com/yudai/gojsondiff/blob/master/gojsondiff.go#L389-L399
+		sim = 0.6
+	}
+	return math.Max(0, math.Min(1, sim))
+}
+
+// countLeaves returns the number of leaves of a value.
+func countLeaves(v interface{}) int {
+	n := 0
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			n += countLeaves(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			n += countLeaves(item)
+		}
+	default:
+		return 1
+	}
+	if n == 0 {
+		return 1 // empty object or array
+	}
+	return n
+}
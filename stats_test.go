@@ -0,0 +1,100 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func ExampleJSONDiff_Stats() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.Compare(
+		[]byte(`{"a": 1, "b": {"c": [1, 2], "d": "x"}, "e": true}`),
+		[]byte(`{"a": 1, "b": {"c": [1, 3, 4], "d": "x"}, "e": true}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s := d.Stats()
+	fmt.Println(s.Added, s.Deleted, s.Modified, s.Unchanged, s.AffectedKeys, s.MaxDepth)
+	fmt.Printf("%.2f\n", s.Similarity)
+	// Output:
+	// 1 0 1 4 [b] 3
+	// 0.81
+}
+
+func TestJSONDiff_Stats(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		expected Stats
+	}
+	tcs := []testCase{
+		{`{"a": [1, 2]}`, `{"a": [1, 2]}`, Stats{Unchanged: 2, Similarity: 1}},
+		{`{}`, `{}`, Stats{Unchanged: 1, Similarity: 1}},
+		{`1`, `"1"`, Stats{Modified: 1, Similarity: 0.3}},
+		{`"abc"`, `"abd"`, Stats{Modified: 1, Similarity: 0.6 + 0.4*4.0/9}},
+		{`""`, `"a"`, Stats{Modified: 1, Similarity: 0.6}},
+		{`-1`, `1`, Stats{Modified: 1, Similarity: 0.2}},
+		{`2`, `0`, Stats{Modified: 1, Similarity: 0.6}},
+		{
+			`{"a": 1, "b": 2, "c": {"d": 1}}`,
+			`{"a": 1.01, "c": {"d": 1, "e": [1, {}]}, "f": null}`,
+			Stats{
+				Added:        3,
+				Deleted:      1,
+				Unchanged:    2,
+				Tolerated:    1,
+				AffectedKeys: []string{"b", "c", "f"},
+				MaxDepth:     2,
+				Similarity:   (1 + 0 + 3*(1.0/3) + 0) / 6,
+			},
+		},
+		{`{"a": {"b": 1.05}}`, `{"a": {"b": 1}}`, Stats{Unchanged: 1, Tolerated: 1, Similarity: 1}},
+		{`[[1, 2.05]]`, `[[1, 2]]`, Stats{Unchanged: 2, Tolerated: 1, Similarity: 1}},
+		{
+			`[1, [2, 3]]`,
+			`[1]`,
+			Stats{Deleted: 2, Unchanged: 1, AffectedKeys: []string{"1"}, MaxDepth: 1, Similarity: 1.0 / 3},
+		},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := d.Stats()
+		if math.Abs(actual.Similarity-tc.expected.Similarity) < 1e-9 {
+			actual.Similarity = tc.expected.Similarity
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("[%v == %v] expected %+v; got %+v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestJSONDiff_Stats_cached(t *testing.T) {
+	d, err := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}.Compare([]byte(`{"a": 1}`), []byte(`{"b": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Stats()
+		}()
+	}
+	wg.Wait()
+
+	// modifying the returned statistics doesn't affect the cached statistics
+	s := d.Stats()
+	s.AffectedKeys[0] = "x"
+	if s = d.Stats(); s.AffectedKeys[0] != "a" || s.Added != 1 || s.Deleted != 1 {
+		t.Errorf("unexpected statistics: %+v", s)
+	}
+}
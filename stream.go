@@ -0,0 +1,384 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// errEnough stops a streaming comparison once enough differences were found.
+var errEnough = errors.New("enough differences")
+
+// StreamDiff represents the differences between two JSON values that were
+// compared while reading them.
+type StreamDiff struct {
+	differences []Difference
+	tolerances  []Tolerance
+	complete    bool
+}
+
+// Differences returns the individual differences between two JSON values.
+func (d *StreamDiff) Differences() []Difference {
+	return d.differences
+}
+
+// Tolerances returns descriptions of basic values that aren't identical, but
+// were considered equal by the BasicEqualer (e.g. because of the StringTransformer
+// of a TolerantBasicEqualer).
+func (d *StreamDiff) Tolerances() []Tolerance {
+	return d.tolerances
+}
+
+// Modified returns true if StreamDiff has at least one Difference.
+func (d *StreamDiff) Modified() bool {
+	return len(d.differences) > 0
+}
+
+// Complete returns false if the comparison was stopped because the maximum
+// number of differences was found, so there may be more differences.
+func (d *StreamDiff) Complete() bool {
+	return d.complete
+}
+
+// Report returns a machine-readable description of the differences between
+// two JSON values.
+func (d *StreamDiff) Report() *Report {
+	return newReport(d.differences, d.tolerances)
+}
+
+// CompareReaders returns the differences between two JSON values, which are
+// read from left and right. It's equivalent to
+// CompareReadersContext(context.Background(), left, right, maxDifferences).
+func (jd JSONDiffer) CompareReaders(left, right io.Reader, maxDifferences int) (*StreamDiff, error) {
+	return jd.CompareReadersContext(context.Background(), left, right, maxDifferences)
+}
+
+// CompareReadersContext returns the differences between two JSON values,
+// which are read from left and right. Unlike Compare, it doesn't decode the values as a
+// whole, but reads them token by token and compares them as it goes. Only
+// values that can't be compared in lockstep are decoded: elements of objects
+// whose keys are in different orders, values that replace values of other
+// types, added and deleted values, and arrays whose elements are matched
+// other than by index (cf. SubsetArrays).
+//
+// If maxDifferences is positive, the comparison stops as soon as that many
+// differences were found. Differences are returned in the order in which
+// they're found, which is the order of the keys in the JSON values rather than
+// alphabetical order. Returns an error if the values don't adhere to the JSON
+// syntax, or if they couldn't be read, and a *LimitError if one of the Limits
+// is exceeded or ctx is done before the comparison is finished. Values that
+// are compared in lockstep count towards Limits.MaxDepth and Limits.MaxNodes
+// just like decoded values.
+func (jd JSONDiffer) CompareReadersContext(ctx context.Context, left, right io.Reader, maxDifferences int) (*StreamDiff, error) {
+	c := &streamComparison{
+		jsonComparison: newJSONComparison(jd, ctx),
+		left:           json.NewDecoder(left),
+		right:          json.NewDecoder(right),
+		max:            maxDifferences,
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	d := &StreamDiff{}
+	switch err := c.compareValues(); err {
+	case nil:
+		if err := checkEOF(c.left); err != nil {
+			return nil, err
+		}
+		if err := checkEOF(c.right); err != nil {
+			return nil, err
+		}
+		d.complete = true
+	case errEnough:
+		c.differences = c.differences[:c.max]
+	default:
+		return nil, err
+	}
+	d.differences, d.tolerances = c.differences, c.tolerances
+	return d, nil
+}
+
+// checkEOF returns an error if a decoder hasn't reached the end of its input
+// after the top-level JSON value (ignoring whitespace). Unlike Decoder.More,
+// it doesn't accept closing brackets or braces.
+func checkEOF(dec *json.Decoder) error {
+	_, err := dec.Token()
+	switch err.(type) {
+	case nil, *json.SyntaxError:
+		return errors.New("invalid data after top-level JSON value")
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// streamComparison holds the state of a single comparison of two JSON values
+// that are read token by token.
+type streamComparison struct {
+	*jsonComparison
+	left, right *json.Decoder
+	max         int
+	differences []Difference
+}
+
+// compareValues reads and compares the next value from both sides.
+func (c *streamComparison) compareValues() error {
+	lt, err := c.left.Token()
+	if err != nil {
+		return err
+	}
+	rt, err := c.right.Token()
+	if err != nil {
+		return err
+	}
+
+	ld, lok := lt.(json.Delim)
+	rd, rok := rt.(json.Delim)
+	switch {
+	case lok && rok && ld == rd && ld == '{':
+		if !c.visit() {
+			return c.err
+		}
+		return c.compareObjects()
+	case lok && rok && ld == rd && ld == '[':
+		return c.compareArrays()
+	case !lok && !rok:
+		return c.compareDecoded(lt, rt)
+	}
+
+	// the values are of different types, so there's no point in comparing them in lockstep
+	l, err := decodeRest(c.left, lt)
+	if err != nil {
+		return err
+	}
+	r, err := decodeRest(c.right, rt)
+	if err != nil {
+		return err
+	}
+	return c.compareDecoded(l, r)
+}
+
+// compareObjects compares the elements of two objects whose opening braces
+// have already been read. As long as the keys are in the same order, the
+// elements are compared in lockstep. Otherwise, elements are decoded and
+// remembered until an element with the same key is found on the other side.
+func (c *streamComparison) compareObjects() error {
+	lp, rp := map[string]interface{}{}, map[string]interface{}{}
+	for c.left.More() || c.right.More() {
+		lk, lmore, err := c.key(c.left)
+		if err != nil {
+			return err
+		}
+		rk, rmore, err := c.key(c.right)
+		if err != nil {
+			return err
+		}
+		if lmore && rmore && lk == rk {
+			c.path = append(c.path, gojsondiff.Name(lk))
+			err = c.compareValues()
+			c.path = c.path[:len(c.path)-1]
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if lmore {
+			if err := c.pending(c.left, lk, lp, rp, false); err != nil {
+				return err
+			}
+		}
+		if rmore {
+			if err := c.pending(c.right, rk, rp, lp, true); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.end(); err != nil {
+		return err
+	}
+
+	for _, k := range sortedKeys(lp) {
+		if rule := c.missingRule(c.path.child(gojsondiff.Name(k)), lp[k]); rule != "" {
+			c.tolerate(c.path.child(gojsondiff.Name(k)), lp[k], nil, rule)
+			continue
+		}
+		d := Difference{Path: c.path.child(gojsondiff.Name(k)), Operation: OperationRemove, Left: lp[k]}
+		if err := c.add(d); err != nil {
+			return err
+		}
+	}
+	if c.Subset {
+		return nil
+	}
+	for _, k := range sortedKeys(rp) {
+		if rule := c.missingRule(c.path.child(gojsondiff.Name(k)), rp[k]); rule != "" {
+			c.tolerate(c.path.child(gojsondiff.Name(k)), nil, rp[k], rule)
+			continue
+		}
+		d := Difference{Path: c.path.child(gojsondiff.Name(k)), Operation: OperationAdd, Right: rp[k]}
+		if err := c.add(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key reads the next key of an object, if there are more elements.
+func (c *streamComparison) key(dec *json.Decoder) (string, bool, error) {
+	if !dec.More() {
+		return "", false, nil
+	}
+	t, err := dec.Token()
+	if err != nil {
+		return "", false, err
+	}
+	return t.(string), true, nil
+}
+
+// pending decodes the value of an object element. If an element with the
+// same key was already decoded on the other side, the values are compared.
+// Otherwise, the value is remembered.
+func (c *streamComparison) pending(dec *json.Decoder, key string, own, other map[string]interface{}, right bool) error {
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	ov, ok := other[key]
+	if !ok {
+		own[key] = v
+		return nil
+	}
+	delete(other, key)
+	l, r := ov, v
+	if !right {
+		l, r = v, ov
+	}
+	c.path = append(c.path, gojsondiff.Name(key))
+	err := c.compareDecoded(l, r)
+	c.path = c.path[:len(c.path)-1]
+	return err
+}
+
+// compareArrays compares the elements of two arrays whose opening brackets
+// have already been read. Like Compare, it compares elements index by index,
+// unless the elements of subsets are matched in another way, in which case
+// the arrays are decoded.
+func (c *streamComparison) compareArrays() error {
+	if c.Subset && c.SubsetArrays != IndexMatching {
+		l, err := decodeRest(c.left, json.Delim('['))
+		if err != nil {
+			return err
+		}
+		r, err := decodeRest(c.right, json.Delim('['))
+		if err != nil {
+			return err
+		}
+		return c.compareDecoded(l, r)
+	}
+	if !c.visit() {
+		return c.err
+	}
+	i := 0
+	for ; c.left.More() && c.right.More(); i++ {
+		c.path = append(c.path, gojsondiff.Index(i))
+		err := c.compareValues()
+		c.path = c.path[:len(c.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+	for j := i; c.left.More(); j++ {
+		var v interface{}
+		if err := c.left.Decode(&v); err != nil {
+			return err
+		}
+		if err := c.add(Difference{Path: c.path.child(gojsondiff.Index(j)), Operation: OperationRemove, Left: v}); err != nil {
+			return err
+		}
+	}
+	for j := i; c.right.More(); j++ {
+		var v interface{}
+		if err := c.right.Decode(&v); err != nil {
+			return err
+		}
+		if c.Subset {
+			continue
+		}
+		if err := c.add(Difference{Path: c.path.child(gojsondiff.Index(j)), Operation: OperationAdd, Right: v}); err != nil {
+			return err
+		}
+	}
+	return c.end()
+}
+
+// end reads the closing braces or brackets of two objects or arrays.
+func (c *streamComparison) end() error {
+	if _, err := c.left.Token(); err != nil {
+		return err
+	}
+	_, err := c.right.Token()
+	return err
+}
+
+// compareDecoded compares two decoded values like Compare.
+func (c *streamComparison) compareDecoded(l, r interface{}) error {
+	same, delta := c.compare(nil, l, r)
+	if c.err != nil {
+		return c.err
+	}
+	if !same {
+		for _, d := range appendDifferences(nil, c.path.clone(), delta) {
+			if err := c.add(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// add records a difference. It returns errEnough if the maximum number of
+// differences has been reached.
+func (c *streamComparison) add(d Difference) error {
+	c.differences = append(c.differences, d)
+	if c.max > 0 && len(c.differences) >= c.max {
+		return errEnough
+	}
+	return nil
+}
+
+// decodeRest decodes the rest of a value whose first token has already been read.
+func decodeRest(dec *json.Decoder, t json.Token) (interface{}, error) {
+	switch t {
+	case json.Delim('{'):
+		m := map[string]interface{}{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			m[k.(string)] = v
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := dec.Token()
+		return a, err
+	}
+	return t, nil
+}
@@ -0,0 +1,149 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func ExampleJSONDiffer_CompareReaders() {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	d, err := jd.CompareReaders(
+		strings.NewReader(`{"x": 1.6, "y": [3.8, "hello"], "z": {"a": 1, "b": 2}}`),
+		strings.NewReader(`{"z": {"b": 2, "a": 1}, "y": [3.6, "hello", null], "x": 1.57}`),
+		0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path.Pointer(), diff.Operation, diff.Left, diff.Right)
+	}
+	fmt.Println(len(d.Tolerances()), d.Complete())
+	// Output:
+	// /y/0 replace 3.8 3.6
+	// /y/2 add <nil> <nil>
+	// 1 true
+}
+
+func TestJSONDiffer_CompareReaders(t *testing.T) {
+	// the differences must be the same as the differences found by Compare,
+	// except for their order
+	type testCase struct {
+		a string
+		b string
+	}
+	tcs := []testCase{
+		{`1`, `1`},
+		{`1`, `2`},
+		{`"a"`, `{"a": 1}`},
+		{`[1, [2, 3]]`, `{"a": [1]}`},
+		{`[]`, `[1, 2]`},
+		{`[1, 2, {"a": 3}]`, `[1]`},
+		{`{"a": 1, "b": 2, "c": 3}`, `{"c": 3, "b": 4, "a": 1}`},
+		{`{"a": {"b": [1, 2]}, "c": 1}`, `{"d": 1, "a": {"b": [1, 3]}}`},
+		{`{"a": {"x": 1}, "b": 1}`, `{"b": 1, "a": {"x": 2}}`},
+		{`{"a": [1, {"b": null}], "c": true}`, `{"a": [1, {"b": false}], "c": true, "d": {}}`},
+		{`{"a": 1}`, `{}`},
+		{`{}`, `{"a": 1}`},
+		{`{"a": "foo ", "b": 1.01}`, `{"b": 1, "a": "foo"}`},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1, StringTransformer: SpaceTrimmer{}}}
+	for _, tc := range tcs {
+		expected, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := jd.CompareReaders(strings.NewReader(tc.a), strings.NewReader(tc.b), 0)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+			continue
+		}
+		if e, a := describeDifferences(expected.Differences()), describeDifferences(actual.Differences()); e != a {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, e, a)
+		}
+		if e, a := describeTolerances(expected.Tolerances()), describeTolerances(actual.Tolerances()); e != a {
+			t.Errorf("[%v == %v] expected tolerances %v; got %v", tc.a, tc.b, e, a)
+		}
+		if !actual.Complete() || actual.Modified() != expected.Modified() {
+			t.Errorf("[%v == %v] expected complete comparison (modified: %v)", tc.a, tc.b, expected.Modified())
+		}
+	}
+}
+
+func TestJSONDiffer_CompareReaders_maxDifferences(t *testing.T) {
+	// the comparison must stop before reading the broken part of the input
+	broken := io.MultiReader(
+		strings.NewReader(`{"a": [1, 2, 3], "b": {"c": 4, "d": 5}, "e": `),
+		&failingReader{errors.New("broken")})
+	right := strings.NewReader(`{"a": [1, 3], "b": {"c": 5, "d": 6}, "e": 7}`)
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.CompareReaders(broken, right, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[/a/1 replace 2 3; /a/2 remove 3 <nil>; /b/c replace 4 5]"
+	if actual := describeDifferences(d.Differences()); actual != expected || d.Complete() {
+		t.Errorf("expected incomplete %v; got %v (complete: %v)", expected, actual, d.Complete())
+	}
+}
+
+func TestJSONDiffer_CompareReaders_errors(t *testing.T) {
+	type testCase struct {
+		a string
+		b string
+	}
+	tcs := []testCase{
+		{`{"a": 1`, `{"a": 1}`},
+		{`{"a": 1}`, `{"a" 1}`},
+		{`[1, 2`, `[1]`},
+		{`1 2`, `1`},
+		{`1`, `1 {}`},
+		{``, `1`},
+		{`{"a": [1, }`, `{"a": 1}`},
+		{`{"a": 1}}`, `{"a": 1}`},
+		{`[1]`, `[1]]`},
+		{`{"a": 1} x`, `{"a": 1}`},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		if _, err := jd.CompareReaders(strings.NewReader(tc.a), strings.NewReader(tc.b), 0); err == nil {
+			t.Errorf("[%v == %v] expected error", tc.a, tc.b)
+		}
+	}
+	if _, err := jd.CompareReaders(&failingReader{io.ErrUnexpectedEOF}, strings.NewReader(`1`), 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v; got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+// failingReader is an io.Reader that always fails.
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// describeDifferences returns a sorted description of differences.
+func describeDifferences(diffs []Difference) string {
+	var ds []string
+	for _, d := range diffs {
+		ds = append(ds, fmt.Sprintf("%s %s %v %v", d.Path.Pointer(), d.Operation, d.Left, d.Right))
+	}
+	sort.Strings(ds)
+	return "[" + strings.Join(ds, "; ") + "]"
+}
+
+// describeTolerances returns a sorted description of tolerances.
+func describeTolerances(ts []Tolerance) string {
+	var ds []string
+	for _, t := range ts {
+		ds = append(ds, fmt.Sprintf("%s %v %v %s", t.Path.Pointer(), t.Left, t.Right, t.Rule))
+	}
+	sort.Strings(ds)
+	return "[" + strings.Join(ds, "; ") + "]"
+}
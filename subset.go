@@ -0,0 +1,192 @@
+package compare
+
+import (
+	"reflect"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// ArrayMatching specifies how the elements of the left array (or slice) are
+// matched with the elements of the right array when checking whether the
+// left value is a subset of the right value (cf. JSONDiffer.Subset and
+// DeepEqualer.Subset).
+type ArrayMatching int
+
+const (
+	// IndexMatching specifies that each left element is compared with the
+	// right element at the same index. Additional right elements are ignored.
+	IndexMatching ArrayMatching = iota
+	// InOrderMatching specifies that the left elements must be equal to right
+	// elements in the same order, but there may be other right elements
+	// before, between and after them.
+	InOrderMatching
+	// AnyOrderMatching specifies that each left element must be equal to a
+	// different right element, regardless of their order.
+	AnyOrderMatching
+)
+
+// equalSubsetElements compares the elements of two slices or arrays if the
+// DeepEqualer specifies Subset. Left elements that can't be matched with
+// right elements (cf. SubsetArrays) are reported as removed.
+func (c *deepComparison) equalSubsetElements(v1, v2 reflect.Value) (bool, error) {
+	if v1.Len() > v2.Len() && !c.collect {
+		return false, nil
+	}
+	if c.SubsetArrays == IndexMatching {
+		common := v1.Len()
+		if v2.Len() < common {
+			common = v2.Len()
+		}
+		same, err := c.each(common, func(c *deepComparison, i int) (bool, bool, error) {
+			return c.equalChild(gojsondiff.Index(i), v1.Index(i), v2.Index(i))
+		})
+		if err != nil || (!same && !c.collect) {
+			return false, err
+		}
+		for i := common; i < v1.Len(); i++ {
+			same = false
+			c.record(Difference{Path: c.path.child(gojsondiff.Index(i)), Operation: OperationRemove, Left: valueOf(v1.Index(i))})
+		}
+		return same, nil
+	}
+
+	matches, err := c.matchSubset(v1, v2)
+	if err != nil {
+		return false, err
+	}
+	same := true
+	for i, j := range matches {
+		if j < 0 {
+			if !c.collect {
+				return false, nil
+			}
+			same = false
+			c.record(Difference{Path: c.path.child(gojsondiff.Index(i)), Operation: OperationRemove, Left: valueOf(v1.Index(i))})
+			continue
+		}
+		if c.collect {
+			// compare the elements again to record tolerances
+			if _, _, err := c.equalChild(gojsondiff.Index(i), v1.Index(i), v2.Index(j)); err != nil {
+				return false, err
+			}
+		}
+	}
+	return same, nil
+}
+
+// matchSubset matches the elements of two slices or arrays according to
+// SubsetArrays (cf. matchElements). Unless we collect all differences, it
+// stops at the first left element that can't be matched.
+func (c *deepComparison) matchSubset(v1, v2 reflect.Value) ([]int, error) {
+	if c.SubsetArrays != InOrderMatching {
+		return c.matchUnordered(v1, v2)
+	}
+	matches := make([]int, 0, v1.Len())
+	from := 0
+	for i := 0; i < v1.Len(); i++ {
+		j, err := c.match(v1.Index(i), v2, from)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, j)
+		if j >= 0 {
+			from = j + 1
+		} else if !c.collect {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// subsetSliceDeltas returns Deltas for the elements of the left array that
+// can't be matched with elements of the right array, if the JSONDiffer
+// specifies Subset and SubsetArrays isn't IndexMatching.
+func (c *jsonComparison) subsetSliceDeltas(left, right []interface{}) []gojsondiff.Delta {
+	var ds []gojsondiff.Delta
+	for i, j := range c.matchElements(left, right, true) {
+		if j < 0 {
+			c.difference(c.path.child(gojsondiff.Index(i)))
+			ds = append(ds, gojsondiff.NewDeleted(gojsondiff.Index(i), left[i]))
+		} else {
+			// compare the elements again to record tolerances
+			c.compareChild(gojsondiff.Index(i), left[i], right[j])
+		}
+		if c.err != nil {
+			return nil
+		}
+	}
+	return ds
+}
+
+// equalSubsetSlices determines if the elements of the left array can be
+// matched with elements of the right array, if the JSONDiffer specifies Subset.
+func (c *jsonComparison) equalSubsetSlices(left, right []interface{}) bool {
+	if len(left) > len(right) {
+		return false
+	}
+	if c.SubsetArrays == IndexMatching {
+		for i := range left {
+			if !c.equalChild(gojsondiff.Index(i), left[i], right[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, j := range c.matchElements(left, right, false) {
+		if j < 0 {
+			return false
+		}
+	}
+	return c.err == nil
+}
+
+// matchElements matches the elements of the left array with elements of the
+// right array that they're equal to. It returns the indexes of the matched
+// right elements, or -1 for left elements that can't be matched. Unless all
+// is true, it stops at the first of them.
+//
+// If SubsetArrays is InOrderMatching, each left element is matched with the
+// first equal right element after the previously matched one, which finds
+// matches for all left elements if that's possible at all. Otherwise, as many
+// left elements as possible are matched with different right elements
+// (cf. maximumMatching), since the first equal right element may be the only
+// one that a later left element is contained in.
+func (c *jsonComparison) matchElements(left, right []interface{}, all bool) []int {
+	equal := func(i, j int) (bool, error) {
+		m := &jsonComparison{
+			JSONDiffer: c.JSONDiffer,
+			path:       c.path.child(gojsondiff.Index(i)),
+			ctx:        c.ctx,
+			counters:   c.counters,
+			defaults:   c.defaults,
+		}
+		same := m.equal(left[i], right[j])
+		return same, m.err
+	}
+	if c.SubsetArrays != InOrderMatching {
+		matches, err := maximumMatching(len(left), len(right), all, equal)
+		c.err = err
+		return matches
+	}
+
+	matches := make([]int, 0, len(left))
+	from := 0
+	for i := range left {
+		match := -1
+		for j := from; j < len(right) && match < 0; j++ {
+			same, err := equal(i, j)
+			if err != nil {
+				c.err = err
+				return nil
+			}
+			if same {
+				match, from = j, j+1
+			}
+		}
+		matches = append(matches, match)
+		if match < 0 && !all {
+			break
+		}
+	}
+	return matches
+}
@@ -0,0 +1,254 @@
+package compare
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func ExampleJSONDiffer_Subset() {
+	jd := JSONDiffer{
+		BasicEqualer: TolerantBasicEqualer{},
+		Subset:       true,
+		SubsetArrays: AnyOrderMatching,
+	}
+	d, err := jd.Compare(
+		[]byte(`{"id": 1, "tags": ["b", "c"], "owner": {"name": "x"}}`),
+		[]byte(`{"id": 1, "tags": ["a", "b"], "owner": {"name": "y", "email": "y@example.com"}, "created": "today"}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.Operation, diff.Left, diff.Right)
+	}
+	// Output:
+	// owner.name replace x y
+	// tags[1] remove c <nil>
+}
+
+func TestJSONDiffer_Equal_subset(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		matching ArrayMatching
+		expected string // differences reported by Compare and CompareReaders
+	}
+	tcs := []testCase{
+		{`{}`, `{"a": 1}`, IndexMatching, "[]"},
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, IndexMatching, "[]"},
+		{`{"a": 1, "b": 2}`, `{"a": 1}`, IndexMatching, "[/b remove 2 <nil>]"},
+		{`{"a": {"b": 1}}`, `{"a": {"b": 1, "c": 2}}`, IndexMatching, "[]"},
+		{`{"a": {"b": 1}}`, `{"a": {"b": 2, "c": 2}}`, IndexMatching, "[/a/b replace 1 2]"},
+		{`{"a": 1}`, `{"a": "1"}`, IndexMatching, "[/a replace 1 1]"},
+		{`{"a": []}`, `{"a": null}`, IndexMatching, "[/a replace [] <nil>]"},
+		{`[1, 2]`, `[1, 2, 3]`, IndexMatching, "[]"},
+		{`[1, 2]`, `[2, 1]`, IndexMatching, "[/0 replace 1 2; /1 replace 2 1]"},
+		{`[1, 2, 3]`, `[1, 2]`, IndexMatching, "[/2 remove 3 <nil>]"},
+		{`[{"a": 1}]`, `[{"a": 1, "b": 2}, {}]`, IndexMatching, "[]"},
+		{`[1, 3]`, `[1, 2, 3]`, InOrderMatching, "[]"},
+		{`[3, 1]`, `[1, 2, 3]`, InOrderMatching, "[/1 remove 1 <nil>]"},
+		{`[1, 1]`, `[1, 2]`, InOrderMatching, "[/1 remove 1 <nil>]"},
+		{`[1, 4, 2]`, `[1, 2, 3]`, InOrderMatching, "[/1 remove 4 <nil>]"},
+		{`[{"a": 2}]`, `[{"a": 1}, {"a": 2, "b": 3}]`, InOrderMatching, "[]"},
+		{`[3, 1]`, `[1, 2, 3]`, AnyOrderMatching, "[]"},
+		{`[1, 1]`, `[1, 2]`, AnyOrderMatching, "[/1 remove 1 <nil>]"},
+		{`[1, 1]`, `[1, 2, 1]`, AnyOrderMatching, "[]"},
+		{`[[2], 4]`, `[3, [1, 2]]`, AnyOrderMatching, "[/1 remove 4 <nil>]"},
+		{`{"a": [1, 2, 3]}`, `{"a": [3], "b": [4]}`, AnyOrderMatching, "[/a/0 remove 1 <nil>; /a/1 remove 2 <nil>]"},
+		// the first element is contained in both right elements, but the second one only in the first
+		{`[{"a": 1}, {"a": 1, "b": 2}]`, `[{"a": 1, "b": 2}, {"a": 1}]`, AnyOrderMatching, "[]"},
+		{`[{"a": 1}, {"a": 1, "b": 2}, {"b": 2}]`, `[{"a": 1, "b": 2}, {"a": 1}]`, AnyOrderMatching, "[/2 remove map[b:2] <nil>]"},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, Subset: true, SubsetArrays: tc.matching}
+		actual, err := jd.Equal([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != (tc.expected == "[]") {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected == "[]", actual)
+		}
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual := describeDifferences(d.Differences()); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+		sd, err := jd.CompareReaders(strings.NewReader(tc.a), strings.NewReader(tc.b), 0)
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual := describeDifferences(sd.Differences()); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestJSONDiffer_Compare_subsetTolerances(t *testing.T) {
+	jd := JSONDiffer{
+		BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1},
+		Subset:       true,
+		SubsetArrays: InOrderMatching,
+		Parallelism:  Parallelism{Workers: 2, Threshold: 1},
+	}
+	d, err := jd.Compare([]byte(`{"a": [1, 2.95], "b": 1}`), []byte(`{"a": [0, 1.05, 2, 3], "b": 1.05, "c": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Modified() {
+		t.Errorf("expected no differences; got %v", describeDifferences(d.Differences()))
+	}
+	expected := "[/a/0 1 1.05 Float64Tolerance: 0.1; /a/1 2.95 3 Float64Tolerance: 0.1; /b 1 1.05 Float64Tolerance: 0.1]"
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+
+	jd.Limits = Limits{MaxNodes: 5}
+	if _, err := jd.Equal([]byte(`[5]`), []byte(`[1, 2, 3, 4, 5]`)); describeLimitError(err) != `"/0": MaxNodes` {
+		t.Errorf("expected %v; got %v", `"/0": MaxNodes`, err)
+	}
+}
+
+func TestJSONDiff_Format_subset(t *testing.T) {
+	// right elements that the left elements don't need to contain are omitted
+	type testCase struct {
+		a        string
+		b        string
+		matching ArrayMatching
+		format   string
+		stats    string // added, deleted, modified, unchanged and tolerated leaves, affected keys
+	}
+	tcs := []testCase{
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, IndexMatching, " {\n   \"a\": 1\n }\n", "0 0 0 1 0 []"},
+		{`{"a": 1, "c": {"d": 1}}`, `{"a": 2, "b": 2, "c": {"d": 1, "e": 1}}`, IndexMatching, " {\n-  \"a\": 1,\n+  \"a\": 2,\n   \"c\": {\n     \"d\": 1\n   }\n }\n", "0 0 1 1 0 [a]"},
+		{`[1, {"k": 2}]`, `[1, {"k": 1, "x": 1}, 3]`, IndexMatching, " [\n   0: 1,\n   1: {\n-    \"k\": 2\n+    \"k\": 1\n   }\n ]\n", "0 0 1 1 0 [1]"},
+		{`[2, {"k": 1}]`, `[{"k": 1}, 1, 2]`, AnyOrderMatching, " [\n   0: 2,\n   1: {\n     \"k\": 1\n   }\n ]\n", "0 0 0 2 0 []"},
+		{`[3, {"k": 1}]`, `[{"k": 1, "x": 1}, 1, 2]`, AnyOrderMatching, " [\n-  0: 3,\n   1: {\n     \"k\": 1\n   }\n ]\n", "0 1 0 1 0 [0]"},
+		{`[{"k": 1.05}, 3]`, `[0, {"k": 1}]`, InOrderMatching, " [\n   0: {\n     \"k\": 1.05\n   },\n-  1: 3\n ]\n", "0 1 0 1 1 [1]"},
+	}
+	for _, tc := range tcs {
+		jd := JSONDiffer{
+			BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1},
+			Subset:       true,
+			SubsetArrays: tc.matching,
+		}
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, err := d.Format(false); err != nil || actual != tc.format {
+			t.Errorf("[%v == %v] expected %q; got %q (%v)", tc.a, tc.b, tc.format, actual, err)
+		}
+		s := d.Stats()
+		if actual := fmt.Sprint(s.Added, s.Deleted, s.Modified, s.Unchanged, s.Tolerated, s.AffectedKeys); actual != tc.stats {
+			t.Errorf("[%v == %v] expected stats %v; got %v", tc.a, tc.b, tc.stats, actual)
+		}
+		if _, err := d.FormatWithOptions(FormatOptions{SideBySide: true, MaxWidth: 80}); err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		}
+		if err := d.WriteHTML(ioutil.Discard, HTMLOptions{}); err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_subset(t *testing.T) {
+	type item struct {
+		ID   int
+		Tags []string
+	}
+	type order struct {
+		Items    []item
+		Keyed    []item `compare:"key=ID"`
+		Labels   map[string]string
+		Quantity int
+	}
+	type testCase struct {
+		a        order
+		b        order
+		matching ArrayMatching
+		expected string
+	}
+	tcs := []testCase{
+		{order{}, order{Items: []item{{ID: 1}}, Labels: map[string]string{"a": "b"}}, IndexMatching, "[]"},
+		{order{Quantity: 1}, order{Quantity: 2}, IndexMatching, "[/Quantity replace 1 2]"},
+		{order{Labels: map[string]string{"a": "b"}}, order{Labels: map[string]string{"a": "b", "c": "d"}}, IndexMatching, "[]"},
+		{order{Labels: map[string]string{"a": "b", "c": "d"}}, order{Labels: map[string]string{"a": "x"}}, IndexMatching,
+			"[/Labels/a replace b x; /Labels/c remove d <nil>]"},
+		{order{Labels: map[string]string{"a": "b"}}, order{}, IndexMatching, "[/Labels/a remove b <nil>]"},
+		{order{Items: []item{{ID: 1}}}, order{Items: []item{{ID: 1, Tags: []string{"x"}}, {ID: 2}}}, IndexMatching, "[]"},
+		{order{Items: []item{{ID: 2}}}, order{Items: []item{{ID: 1}, {ID: 2}}}, IndexMatching, "[/Items/0/ID replace 2 1]"},
+		{order{Items: []item{{ID: 1}, {ID: 2}}}, order{Items: []item{{ID: 1}}}, IndexMatching, "[/Items/1 remove {2 []} <nil>]"},
+		{order{Items: []item{{ID: 2}}}, order{Items: []item{{ID: 1}, {ID: 2}}}, InOrderMatching, "[]"},
+		{order{Items: []item{{ID: 2}, {ID: 1}}}, order{Items: []item{{ID: 1}, {ID: 2}}}, InOrderMatching, "[/Items/1 remove {1 []} <nil>]"},
+		{order{Items: []item{{ID: 2}, {ID: 1}}}, order{Items: []item{{ID: 1}, {ID: 2}}}, AnyOrderMatching, "[]"},
+		{order{Items: []item{{Tags: []string{"b"}}}}, order{Items: []item{{Tags: []string{"a", "b"}}}}, AnyOrderMatching, "[]"},
+		{order{Items: []item{{ID: 3}}}, order{Items: []item{{ID: 1}, {ID: 2}}}, AnyOrderMatching, "[/Items/0 remove {3 []} <nil>]"},
+		{order{Keyed: []item{{ID: 2}}}, order{Keyed: []item{{ID: 1}, {ID: 2, Tags: []string{"x"}}}}, IndexMatching, "[]"},
+		{order{Keyed: []item{{ID: 3}}}, order{Keyed: []item{{ID: 1}}}, IndexMatching, "[/Keyed/3 remove {3 []} <nil>]"},
+	}
+	for i, tc := range tcs {
+		for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+			e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Parallelism: p, Subset: true, SubsetArrays: tc.matching}
+			actual, err := e.Equal(tc.a, tc.b)
+			if err != nil {
+				t.Errorf("[%d] %v", i, err)
+			} else if actual != (tc.expected == "[]") {
+				t.Errorf("[%d] expected %v; got %v", i, tc.expected == "[]", actual)
+			}
+			d, err := e.Compare(tc.a, tc.b)
+			if err != nil {
+				t.Errorf("[%d] %v", i, err)
+			} else if actual := describeDifferences(d.Differences()); actual != tc.expected {
+				t.Errorf("[%d] expected %v; got %v", i, tc.expected, actual)
+			}
+		}
+	}
+}
+
+func TestDeepEqualer_Equal_subsetAnyOrder(t *testing.T) {
+	// the first element is contained in both right elements, but the second one only in the first
+	a := []map[string]int{{"a": 1}, {"a": 1, "b": 2}}
+	b := []map[string]int{{"a": 1, "b": 2}, {"a": 1}}
+	for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}, Parallelism: p, Subset: true, SubsetArrays: AnyOrderMatching}
+		if same, err := e.Equal(a, b); err != nil || !same {
+			t.Errorf("expected equal; got %v (%v)", same, err)
+		}
+		if d, err := e.Compare(a, b); err != nil || d.Modified() {
+			t.Errorf("expected no differences; got %v (%v)", d, err)
+		}
+		if d, err := e.Compare(append(a, map[string]int{"c": 3}), b); err != nil {
+			t.Error(err)
+		} else if expected := "[/2 remove map[c:3] <nil>]"; describeDifferences(d.Differences()) != expected {
+			t.Errorf("expected %v; got %v", expected, describeDifferences(d.Differences()))
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_subsetArrays(t *testing.T) {
+	type testCase struct {
+		a        [3]float64
+		b        [3]float64
+		matching ArrayMatching
+		expected string
+	}
+	tcs := []testCase{
+		{[3]float64{1, 2, 3}, [3]float64{1, 2, 3.05}, IndexMatching, "[/2 3 3.05 Float64Tolerance: 0.1]"},
+		{[3]float64{3, 1, 2}, [3]float64{1, 2, 3.05}, AnyOrderMatching, "[/0 3 3.05 Float64Tolerance: 0.1]"},
+		{[3]float64{0, 1, 2}, [3]float64{0, 1.05, 2}, InOrderMatching, "[/1 1 1.05 Float64Tolerance: 0.1]"},
+	}
+	for _, tc := range tcs {
+		e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}, Subset: true, SubsetArrays: tc.matching}
+		d, err := e.Compare(tc.a, tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := describeTolerances(d.Tolerances()); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+		if d.Modified() {
+			t.Errorf("[%v == %v] expected no differences; got %v", tc.a, tc.b, describeDifferences(d.Differences()))
+		}
+	}
+}
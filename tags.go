@@ -0,0 +1,426 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yudai/gojsondiff"
+)
+
+// tagName is the key of struct tags that specify how a DeepEqualer compares fields.
+const tagName = "compare"
+
+// fieldOptions are the options specified by the struct tag of a field, e.g.
+// `compare:"tol=0.01,unordered"` (cf. DeepEqualer.Equal).
+type fieldOptions struct {
+	tag       string
+	ignore    bool
+	tol       float64
+	relTol    float64
+	timeTol   time.Duration
+	unordered bool
+	key       string
+	transform string
+}
+
+// A TagError is returned by a DeepEqualer if the struct tag of a field is invalid.
+type TagError struct {
+	// Struct is the type of the struct.
+	Struct reflect.Type
+	// Field is the name of the field.
+	Field string
+	// Tag is the value of the struct tag.
+	Tag string
+	// Err describes what's wrong with the tag.
+	Err error
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("invalid tag %s:%q on %v.%s: %v", tagName, e.Tag, e.Struct, e.Field, e.Err)
+}
+
+// Unwrap returns the error describing what's wrong with the tag.
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// structOptionsCache maps struct types to the options of their fields (or to
+// a *TagError), since parsing the tags on every comparison would be wasteful.
+var structOptionsCache sync.Map
+
+// structOptions returns the options of the fields of a struct type.
+func structOptions(t reflect.Type) ([]fieldOptions, error) {
+	if cached, ok := structOptionsCache.Load(t); ok {
+		if err, ok := cached.(error); ok {
+			return nil, err
+		}
+		return cached.([]fieldOptions), nil
+	}
+	opts := make([]fieldOptions, t.NumField())
+	for i := range opts {
+		f := t.Field(i)
+		var err error
+		if opts[i], err = parseFieldOptions(f.Tag.Get(tagName)); err != nil {
+			err = &TagError{Struct: t, Field: f.Name, Tag: f.Tag.Get(tagName), Err: err}
+			structOptionsCache.Store(t, err)
+			return nil, err
+		}
+	}
+	structOptionsCache.Store(t, opts)
+	return opts, nil
+}
+
+// parseFieldOptions parses the value of a struct tag.
+func parseFieldOptions(tag string) (fieldOptions, error) {
+	opts := fieldOptions{tag: tag}
+	if tag == "" {
+		return opts, nil
+	}
+	if tag == "-" {
+		opts.ignore = true
+		return opts, nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		name, value := opt, ""
+		if i := strings.Index(opt, "="); i >= 0 {
+			name, value = opt[:i], opt[i+1:]
+		}
+		var err error
+		switch name {
+		case "tol":
+			opts.tol, err = parseTolerance(value)
+		case "reltol":
+			opts.relTol, err = parseTolerance(value)
+		case "timetol":
+			if opts.timeTol, err = time.ParseDuration(value); err == nil && opts.timeTol < 0 {
+				err = fmt.Errorf("negative duration %s", value)
+			}
+		case "unordered":
+			opts.unordered = true
+			if value != "" {
+				err = fmt.Errorf("unexpected value for %s", name)
+			}
+		case "key":
+			opts.key = value
+		case "transform":
+			opts.transform = value
+		default:
+			err = fmt.Errorf("unknown option %q", name)
+		}
+		if err != nil {
+			return opts, err
+		}
+		if (name == "key" || name == "transform") && value == "" {
+			return opts, fmt.Errorf("missing value for %s", name)
+		}
+	}
+	return opts, nil
+}
+
+func parseTolerance(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil && (f < 0 || math.IsNaN(f)) {
+		err = fmt.Errorf("invalid tolerance %s", s)
+	}
+	return f, err
+}
+
+// equalField compares the values of a struct field according to its options
+// (cf. equalChild).
+func (c *deepComparison) equalField(t reflect.Type, i int, opts fieldOptions, v1, v2 reflect.Value) (same, stop bool, err error) {
+	if opts.ignore {
+		return true, false, nil
+	}
+	name := gojsondiff.Name(t.Field(i).Name)
+	if opts.tag == "" {
+		return c.equalChild(name, v1, v2)
+	}
+	tagErr := func(err error) (bool, bool, error) {
+		return false, true, &TagError{Struct: t, Field: t.Field(i).Name, Tag: opts.tag, Err: err}
+	}
+
+	if opts.tol > 0 || opts.relTol > 0 || opts.timeTol > 0 || opts.transform != "" {
+		te := tagEqualer{BasicEqualer: c.BasicEqualer, opts: opts}
+		if opts.transform != "" {
+			var ok bool
+			if te.transformer, ok = c.StringTransformers[opts.transform]; !ok {
+				return tagErr(fmt.Errorf("unknown StringTransformer %q", opts.transform))
+			}
+		}
+		// the options also apply to values nested within the field's values
+		defer func(e BasicEqualer) { c.BasicEqualer = e }(c.BasicEqualer)
+		c.BasicEqualer = te
+	}
+
+	if !opts.unordered && opts.key == "" {
+		return c.equalChild(name, v1, v2)
+	}
+	if k := v1.Kind(); k != reflect.Slice && k != reflect.Array {
+		return tagErr(fmt.Errorf("unordered elements or keys require a slice or array, not %v", v1.Type()))
+	}
+	if opts.key != "" {
+		elem := v1.Type().Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return tagErr(fmt.Errorf("%v has no field %s", v1.Type().Elem(), opts.key))
+		}
+		if _, ok := elem.FieldByName(opts.key); !ok {
+			return tagErr(fmt.Errorf("%v has no field %s", v1.Type().Elem(), opts.key))
+		}
+	}
+
+	c.path = append(c.path, name)
+	if opts.key != "" {
+		same, err = c.equalKeyed(v1, v2, opts.key)
+	} else {
+		same, err = c.equalUnordered(v1, v2)
+	}
+	c.path = c.path[:len(c.path)-1]
+	return same, err != nil || (!same && !c.collect), err
+}
+
+// equalUnordered compares the elements of two slices or arrays regardless of
+// their order. The elements on the left side are matched with different
+// elements on the right side that they're equal to, so that as many elements
+// as possible are matched (cf. maximumMatching), which requires up to n*m
+// comparisons of elements. Elements that can't be matched are reported as
+// removed or added (unless the DeepEqualer
+// specifies Subset, in which case unmatched right elements are ignored).
+func (c *deepComparison) equalUnordered(v1, v2 reflect.Value) (bool, error) {
+	if v1.Kind() == reflect.Slice && v1.IsNil() != v2.IsNil() && !c.Subset {
+		return c.equalNilOrEmpty(v1, v2), nil
+	}
+	if v1.Len() != v2.Len() && !c.collect && (v1.Len() > v2.Len() || !c.Subset) {
+		return false, nil
+	}
+	matches, err := c.matchUnordered(v1, v2)
+	if err != nil {
+		return false, err
+	}
+	matched := make([]bool, v2.Len())
+	same := true
+	for i, j := range matches {
+		if j < 0 {
+			if !c.collect {
+				return false, nil
+			}
+			same = false
+			c.record(Difference{Path: c.path.child(gojsondiff.Index(i)), Operation: OperationRemove, Left: valueOf(v1.Index(i))})
+			continue
+		}
+		matched[j] = true
+		if c.collect {
+			// compare the elements again to record tolerances
+			if _, _, err := c.equalChild(gojsondiff.Index(i), v1.Index(i), v2.Index(j)); err != nil {
+				return false, err
+			}
+		}
+	}
+	for j := 0; j < v2.Len() && !c.Subset; j++ {
+		if !matched[j] {
+			same = false
+			c.record(Difference{Path: c.path.child(gojsondiff.Index(j)), Operation: OperationAdd, Right: valueOf(v2.Index(j))})
+		}
+	}
+	return same, nil
+}
+
+// matchUnordered matches the elements of two slices or arrays regardless of
+// their order (cf. maximumMatching).
+func (c *deepComparison) matchUnordered(v1, v2 reflect.Value) ([]int, error) {
+	m := &deepComparison{DeepEqualer: c.DeepEqualer, path: c.path.clone(), filter: c.filter, defaults: c.defaults}
+	return maximumMatching(v1.Len(), v2.Len(), c.collect, func(i, j int) (bool, error) {
+		return m.equal(v1.Index(i), v2.Index(j))
+	})
+}
+
+// match returns the index of the first element of v2 (starting at index
+// from) that's equal to v, or -1 if there's no such element.
+func (c *deepComparison) match(v, v2 reflect.Value, from int) (int, error) {
+	m := &deepComparison{DeepEqualer: c.DeepEqualer, path: c.path.clone(), filter: c.filter, defaults: c.defaults}
+	for j := from; j < v2.Len(); j++ {
+		same, err := m.equal(v, v2.Index(j))
+		if err != nil || same {
+			return j, err
+		}
+	}
+	return -1, nil
+}
+
+// equalKeyed compares the elements of two slices or arrays of structs (or
+// pointers to structs) with the same keys, i.e. the same values of the key
+// field. Elements are identified by their keys' string representations.
+// Elements that can't be matched are reported as removed or added (unless the
+// DeepEqualer specifies Subset, in which case unmatched right elements are
+// ignored).
+func (c *deepComparison) equalKeyed(v1, v2 reflect.Value, key string) (bool, error) {
+	if v1.Kind() == reflect.Slice && v1.IsNil() != v2.IsNil() && !c.Subset {
+		return c.equalNilOrEmpty(v1, v2), nil
+	}
+	if v1.Len() != v2.Len() && !c.collect && (v1.Len() > v2.Len() || !c.Subset) {
+		return false, nil
+	}
+	keys1, err := c.elementKeys(v1, key)
+	if err != nil {
+		return false, err
+	}
+	keys2, err := c.elementKeys(v2, key)
+	if err != nil {
+		return false, err
+	}
+	index2 := make(map[string]int, len(keys2))
+	for j, k := range keys2 {
+		index2[k] = j
+	}
+
+	same := true
+	matched := make(map[string]bool, len(keys1))
+	for i, k := range keys1 {
+		j, ok := index2[k]
+		if !ok {
+			if !c.collect {
+				return false, nil
+			}
+			same = false
+			c.record(Difference{Path: c.path.child(gojsondiff.Name(k)), Operation: OperationRemove, Left: valueOf(v1.Index(i))})
+			continue
+		}
+		matched[k] = true
+		eq, stop, err := c.equalChild(gojsondiff.Name(k), v1.Index(i), v2.Index(j))
+		if stop {
+			return false, err
+		}
+		same = same && eq
+	}
+	for j, k := range keys2 {
+		if !matched[k] && !c.Subset {
+			same = false
+			c.record(Difference{Path: c.path.child(gojsondiff.Name(k)), Operation: OperationAdd, Right: valueOf(v2.Index(j))})
+		}
+	}
+	return same, nil
+}
+
+// elementKeys returns the string representations of the key fields of the
+// elements of a slice or array. Nil pointers don't have keys.
+func (c *deepComparison) elementKeys(v reflect.Value, key string) ([]string, error) {
+	keys := make([]string, v.Len())
+	seen := make(map[string]bool, v.Len())
+	for i := range keys {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				return nil, &KeyError{Path: c.path.clone(), Index: i, Nil: true}
+			}
+			elem = elem.Elem()
+		}
+		keys[i] = fmt.Sprint(elem.FieldByName(key))
+		if seen[keys[i]] {
+			return nil, &KeyError{Path: c.path.clone(), Index: i, Key: keys[i]}
+		}
+		seen[keys[i]] = true
+	}
+	return keys, nil
+}
+
+// tagEqualer applies the options of a struct tag on top of another BasicEqualer.
+type tagEqualer struct {
+	BasicEqualer
+	opts        fieldOptions
+	transformer StringTransformer
+}
+
+// Float64 considers numbers equal if they're within the absolute or relative
+// tolerance, or if the underlying BasicEqualer considers them equal.
+func (e tagEqualer) Float64(a, b float64) bool {
+	return e.withinTolerance(a, b) || e.BasicEqualer.Float64(a, b)
+}
+
+func (e tagEqualer) withinTolerance(a, b float64) bool {
+	diff := math.Abs(a - b)
+	return (e.opts.tol > 0 && diff <= e.opts.tol) ||
+		(e.opts.relTol > 0 && diff <= e.opts.relTol*math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// String transforms the strings before passing them to the underlying BasicEqualer.
+func (e tagEqualer) String(a, b string) bool {
+	if e.transformer != nil {
+		a, b = e.transformer.Transform(a), e.transformer.Transform(b)
+	}
+	return e.BasicEqualer.String(a, b)
+}
+
+// Time considers times equal if they're within the tolerance, or if the
+// underlying BasicEqualer considers them equal.
+func (e tagEqualer) Time(a, b time.Time) bool {
+	if e.withinTimeTolerance(a, b) {
+		return true
+	}
+	if te, ok := e.BasicEqualer.(TimeEqualer); ok {
+		return te.Time(a, b)
+	}
+	return a.Equal(b)
+}
+
+// Duration considers durations equal if they're within the tolerance, or if
+// the underlying BasicEqualer considers them equal.
+func (e tagEqualer) Duration(a, b time.Duration) bool {
+	if e.withinDurationTolerance(a, b) {
+		return true
+	}
+	if te, ok := e.BasicEqualer.(TimeEqualer); ok {
+		return te.Duration(a, b)
+	}
+	return e.BasicEqualer.Int64(int64(a), int64(b))
+}
+
+// withinTimeTolerance compares the times without subtracting them, since
+// a.Sub(b) saturates if they're further apart than the maximum Duration.
+func (e tagEqualer) withinTimeTolerance(a, b time.Time) bool {
+	tol := e.opts.timeTol
+	return tol > 0 && !a.Before(b.Add(-tol)) && !a.After(b.Add(tol))
+}
+
+func (e tagEqualer) withinDurationTolerance(a, b time.Duration) bool {
+	if a < b {
+		a, b = b, a
+	}
+	// the difference always fits into an uint64, even if it would overflow a Duration
+	return e.opts.timeTol > 0 && uint64(a)-uint64(b) <= uint64(e.opts.timeTol)
+}
+
+// DescribeTolerance names the struct tag if one of its options makes two
+// values equal, and otherwise defers to the underlying BasicEqualer.
+func (e tagEqualer) DescribeTolerance(a, b interface{}) string {
+	rule := fmt.Sprintf("%s:%q", tagName, e.opts.tag)
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok && e.withinTolerance(a, b) {
+			return rule
+		}
+	case string:
+		if b, ok := b.(string); ok && e.transformer != nil {
+			a, b := e.transformer.Transform(a), e.transformer.Transform(b)
+			if a == b {
+				return rule
+			}
+			return describeTolerance(e.BasicEqualer, a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok && e.withinTimeTolerance(a, b) {
+			return rule
+		}
+	case time.Duration:
+		if b, ok := b.(time.Duration); ok && e.withinDurationTolerance(a, b) {
+			return rule
+		}
+	}
+	return describeTolerance(e.BasicEqualer, a, b)
+}
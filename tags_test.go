@@ -0,0 +1,296 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ExampleDeepEqualer_Equal_tags() {
+	type line struct {
+		SKU      string
+		Quantity int
+	}
+	type order struct {
+		ID       string    `compare:"-"`
+		Customer string    `compare:"transform=trim"`
+		Total    float64   `compare:"tol=0.01"`
+		Placed   time.Time `compare:"timetol=1s"`
+		Tags     []string  `compare:"unordered"`
+		Lines    []line    `compare:"key=SKU"`
+	}
+	e := DeepEqualer{
+		BasicEqualer:       TolerantBasicEqualer{},
+		StringTransformers: map[string]StringTransformer{"trim": SpaceTrimmer{}},
+	}
+	placed := time.Date(2018, 3, 30, 16, 41, 11, 0, time.UTC)
+	d, err := e.Compare(
+		order{"a", "Alice ", 9.99, placed, []string{"x", "y"}, []line{{"A", 1}, {"B", 2}}},
+		order{"b", "Alice", 10, placed.Add(time.Second), []string{"y", "x"}, []line{{"B", 3}, {"A", 1}}})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.Left, diff.Right)
+	}
+	for _, t := range d.Tolerances() {
+		fmt.Println(t.Path, t.Rule)
+	}
+	// Output:
+	// Lines.B.Quantity 2 3
+	// Customer compare:"transform=trim"
+	// Total compare:"tol=0.01"
+	// Placed compare:"timetol=1s"
+}
+
+func TestDeepEqualer_Equal_tags(t *testing.T) {
+	type item struct {
+		ID    int
+		Price float64
+	}
+	type tagged struct {
+		Ignored  chan int      `compare:"-"`
+		Abs      []float64     `compare:"tol=0.1"`
+		Rel      float32       `compare:"reltol=0.01"`
+		Both     float64       `compare:"tol=0.1,reltol=0.01"`
+		Time     time.Time     `compare:"timetol=1m"`
+		Duration time.Duration `compare:"timetol=1s"`
+		Text     string        `compare:"transform=lower"`
+		Set      []int         `compare:"unordered"`
+		Items    []*item       `compare:"key=ID,tol=0.5"`
+		Array    [3]string     `compare:"unordered,transform=lower"`
+	}
+	now := time.Now()
+	base := tagged{
+		Abs:      []float64{1, 2},
+		Rel:      100,
+		Both:     100,
+		Time:     now,
+		Duration: time.Second,
+		Text:     "Hello",
+		Set:      []int{1, 2, 2, 3},
+		Items:    []*item{{1, 10}, {2, 20}},
+		Array:    [3]string{"a", "B", "c"},
+	}
+	type testCase struct {
+		change   func(*tagged)
+		expected bool
+	}
+	tcs := []testCase{
+		{func(t *tagged) {}, true},
+		{func(t *tagged) { t.Ignored = make(chan int) }, true},
+		{func(t *tagged) { t.Abs = []float64{1.05, 1.95} }, true},
+		{func(t *tagged) { t.Abs = []float64{1.2, 2} }, false},
+		{func(t *tagged) { t.Rel = 101 }, true},
+		{func(t *tagged) { t.Rel = 98.9 }, false},
+		{func(t *tagged) { t.Both = 101 }, true},
+		{func(t *tagged) { t.Both = 101.1 }, false},
+		{func(t *tagged) { t.Time = now.Add(-time.Minute) }, true},
+		{func(t *tagged) { t.Time = now.Add(time.Minute + 1) }, false},
+		{func(t *tagged) { t.Duration = 2 * time.Second }, true},
+		{func(t *tagged) { t.Duration = 0 }, true},
+		{func(t *tagged) { t.Duration = -time.Nanosecond }, false},
+		{func(t *tagged) { t.Text = "HELLO" }, true},
+		{func(t *tagged) { t.Text = "Hallo" }, false},
+		{func(t *tagged) { t.Set = []int{2, 3, 1, 2} }, true},
+		{func(t *tagged) { t.Set = []int{2, 3, 1, 1} }, false},
+		{func(t *tagged) { t.Set = []int{2, 3, 1} }, false},
+		{func(t *tagged) { t.Set = nil }, false},
+		{func(t *tagged) { t.Items = []*item{{2, 20.5}, {1, 9.5}} }, true},
+		{func(t *tagged) { t.Items = []*item{{2, 21}, {1, 10}} }, false},
+		{func(t *tagged) { t.Items = []*item{{3, 20}, {1, 10}} }, false},
+		{func(t *tagged) { t.Items = []*item{{1, 10}} }, false},
+		{func(t *tagged) { t.Array = [3]string{"C", "b", "A"} }, true},
+		{func(t *tagged) { t.Array = [3]string{"C", "b", "b"} }, false},
+	}
+	e := DeepEqualer{
+		BasicEqualer:       TolerantBasicEqualer{},
+		StringTransformers: map[string]StringTransformer{"lower": lowerCaser{}},
+	}
+	for i, tc := range tcs {
+		changed := base
+		changed.Abs = append([]float64(nil), base.Abs...)
+		tc.change(&changed)
+		for _, p := range []Parallelism{{}, {Workers: 2, Threshold: 1}} {
+			e.Parallelism = p
+			actual, err := e.Equal(base, changed)
+			if err != nil {
+				t.Errorf("[%d] %v", i, err)
+			} else if actual != tc.expected {
+				t.Errorf("[%d] expected %v; got %v", i, tc.expected, actual)
+			}
+			// Compare must agree with Equal
+			if d, err := e.Compare(base, changed); err != nil || d.Modified() == tc.expected {
+				t.Errorf("[%d] expected modified: %v; got %v (%v)", i, !tc.expected, d, err)
+			}
+		}
+	}
+}
+
+func TestDeepEqualer_Equal_tagsTimeOverflow(t *testing.T) {
+	// the differences don't fit into a Duration
+	type tagged struct {
+		Time     time.Time     `compare:"timetol=1s"`
+		Duration time.Duration `compare:"timetol=1s"`
+	}
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	type testCase struct {
+		a        tagged
+		b        tagged
+		expected bool
+	}
+	tcs := []testCase{
+		{tagged{Time: time.Time{}}, tagged{Time: date}, false},
+		{tagged{Time: date}, tagged{Time: time.Time{}}, false},
+		{tagged{Time: date}, tagged{Time: date.Add(time.Second)}, true},
+		{tagged{Duration: math.MinInt64}, tagged{Duration: math.MaxInt64}, false},
+		{tagged{Duration: math.MaxInt64}, tagged{Duration: math.MinInt64}, false},
+		{tagged{Duration: math.MinInt64}, tagged{Duration: math.MinInt64 + time.Second}, true},
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		if actual, err := e.Equal(tc.a, tc.b); err != nil || actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v (%v)", tc.a, tc.b, tc.expected, actual, err)
+		}
+	}
+}
+
+func TestDeepEqualer_Compare_unorderedTolerance(t *testing.T) {
+	// tolerances aren't transitive, so 1.05 mustn't take 1.1, which is the only partner of 1.15
+	type tagged struct {
+		X []float64 `compare:"unordered,tol=0.1"`
+	}
+	a, b := tagged{[]float64{1.05, 1.15}}, tagged{[]float64{1.1, 1}}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	if same, err := e.Equal(a, b); err != nil || !same {
+		t.Errorf("expected equal; got %v (%v)", same, err)
+	}
+	d, err := e.Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Modified() {
+		t.Errorf("expected no differences; got %v", describeDifferences(d.Differences()))
+	}
+	expected := `[/X/0 1.05 1 compare:"unordered,tol=0.1"; /X/1 1.15 1.1 compare:"unordered,tol=0.1"]`
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestDeepEqualer_Compare_tags(t *testing.T) {
+	type item struct {
+		ID   string
+		Qty  int
+		Cost float64
+	}
+	type cart struct {
+		Items []item   `compare:"key=ID,tol=0.1"`
+		Codes []string `compare:"unordered"`
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := e.Compare(
+		cart{[]item{{"a", 1, 1}, {"b", 2, 2}, {"c", 3, 3}}, []string{"x", "y", "z"}},
+		cart{[]item{{"d", 4, 4}, {"c", 3, 3.05}, {"a", 2, 1}}, []string{"z", "w", "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[/Codes/1 add <nil> w; /Codes/1 remove y <nil>; /Items/a/Qty replace 1 2; " +
+		"/Items/b remove {b 2 2} <nil>; /Items/d add <nil> {d 4 4}]"
+	if actual := describeDifferences(d.Differences()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+	expected = `[/Items/c/Cost 3 3.05 compare:"key=ID,tol=0.1"]`
+	if actual := describeTolerances(d.Tolerances()); actual != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+}
+
+func TestDeepEqualer_Equal_tagErrors(t *testing.T) {
+	type unknown struct {
+		X int `compare:"fuzzy"`
+	}
+	type badTol struct {
+		X float64 `compare:"tol=-1"`
+	}
+	type badTimeTol struct {
+		X time.Duration `compare:"timetol=soon"`
+	}
+	type badUnordered struct {
+		X []int `compare:"unordered=yes"`
+	}
+	type emptyKey struct {
+		X []int `compare:"key="`
+	}
+	type notSlice struct {
+		X int `compare:"unordered"`
+	}
+	type noKeyField struct {
+		X []struct{ A int } `compare:"key=B"`
+	}
+	type notStructs struct {
+		X []int `compare:"key=B"`
+	}
+	type unknownTransform struct {
+		X string `compare:"transform=upper"`
+	}
+	type outer struct {
+		Inner [1]unknown
+	}
+	type testCase struct {
+		a        interface{}
+		expected string
+	}
+	tcs := []testCase{
+		{unknown{}, `invalid tag compare:"fuzzy" on compare.unknown.X: unknown option "fuzzy"`},
+		{badTol{}, `invalid tag compare:"tol=-1" on compare.badTol.X: invalid tolerance -1`},
+		{badTimeTol{}, `invalid tag compare:"timetol=soon" on compare.badTimeTol.X: time: invalid duration "soon"`},
+		{badUnordered{}, `invalid tag compare:"unordered=yes" on compare.badUnordered.X: unexpected value for unordered`},
+		{emptyKey{}, `invalid tag compare:"key=" on compare.emptyKey.X: missing value for key`},
+		{notSlice{}, `invalid tag compare:"unordered" on compare.notSlice.X: unordered elements or keys require a slice or array, not int`},
+		{noKeyField{}, `invalid tag compare:"key=B" on compare.noKeyField.X: struct { A int } has no field B`},
+		{notStructs{}, `invalid tag compare:"key=B" on compare.notStructs.X: int has no field B`},
+		{unknownTransform{}, `invalid tag compare:"transform=upper" on compare.unknownTransform.X: unknown StringTransformer "upper"`},
+		{outer{[1]unknown{{}}}, `invalid tag compare:"fuzzy" on compare.unknown.X: unknown option "fuzzy"`},
+	}
+	e := DeepEqualer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, tc := range tcs {
+		for i := 0; i < 2; i++ { // errors must be reported consistently
+			_, err := e.Equal(tc.a, tc.a)
+			var te *TagError
+			if !errors.As(err, &te) || err.Error() != tc.expected {
+				t.Errorf("[%T] expected %v; got %v", tc.a, tc.expected, err)
+			}
+		}
+	}
+
+	type duplicate struct {
+		X []struct{ A int } `compare:"key=A"`
+	}
+	a := duplicate{X: []struct{ A int }{{1}, {1}}}
+	if _, err := e.Equal(a, a); err == nil || err.Error() != `duplicate key 1 at "/X"` {
+		t.Errorf("expected duplicate key error; got %v", err)
+	}
+	if te := (&TagError{Struct: reflect.TypeOf(a), Field: "X", Err: errSentinel}); !errors.Is(te, errSentinel) {
+		t.Errorf("expected %v to wrap %v", te, errSentinel)
+	}
+}
+
+var errSentinel = errors.New("sentinel")
+
+// lowerCaser is a StringTransformer that converts strings to lower case.
+type lowerCaser struct{}
+
+func (lowerCaser) Transform(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
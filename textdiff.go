@@ -0,0 +1,124 @@
+package compare
+
+import (
+	"bytes"
+	"unicode"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Granularity specifies which units of text are highlighted as changed when
+// formatting a TextDiff.
+type Granularity int
+
+const (
+	// CharacterGranularity specifies that individual characters are highlighted.
+	CharacterGranularity Granularity = iota
+	// WordGranularity specifies that whole words are highlighted.
+	WordGranularity
+)
+
+// Markers that delimit highlighted text when coloring is disabled,
+// cf. git diff --word-diff
+const (
+	deletedStart  = "[-"
+	deletedEnd    = "-]"
+	insertedStart = "{+"
+	insertedEnd   = "+}"
+)
+
+// textDiffs returns the differences between two strings at the given granularity.
+func textDiffs(a, b string, g Granularity) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	if g == WordGranularity {
+		// cf. diffmatchpatch.DiffLinesToRunes(), but with words instead of lines
+		if ra, rb, words, ok := wordsToRunes(a, b); ok {
+			diffs := dmp.DiffMainRunes(ra, rb, false)
+			return dmp.DiffCharsToLines(diffs, words)
+		}
+		// there are too many distinct words to encode them as runes
+	}
+	return dmp.DiffCleanupSemantic(dmp.DiffMain(a, b, false))
+}
+
+// wordsToRunes splits two strings into words (sequences of letters and digits)
+// and separators (all other characters), and encodes each distinct word or
+// separator as a rune. The returned slice maps runes back to words. Since
+// surrogate halves aren't valid runes, they're skipped. Returns false if there
+// are more distinct words than valid runes.
+func wordsToRunes(a, b string) ([]rune, []rune, []string, bool) {
+	words := []string{""} // rune 0 is reserved, cf. diffmatchpatch.DiffLinesToRunes()
+	index := map[string]rune{}
+	encode := func(s string) ([]rune, bool) {
+		var rs []rune
+		for _, w := range splitWords(s) {
+			r, ok := index[w]
+			if !ok {
+				if len(words) == surrogateMin {
+					words = append(words, make([]string, surrogateMax-surrogateMin+1)...)
+				}
+				if len(words) > unicode.MaxRune {
+					return nil, false
+				}
+				r = rune(len(words))
+				index[w] = r
+				words = append(words, w)
+			}
+			rs = append(rs, r)
+		}
+		return rs, true
+	}
+	ra, ok := encode(a)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	rb, ok := encode(b)
+	return ra, rb, words, ok
+}
+
+// The range of surrogate halves, which can't be encoded in UTF-8.
+const (
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+// splitWords splits a string into words (sequences of letters and digits) and
+// single separator characters.
+func splitWords(s string) []string {
+	var words []string
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = append(words, s[start:i])
+			start = -1
+		}
+		words = append(words, string(r))
+	}
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// highlight renders the text of all unchanged diffs and all diffs of the given
+// type (deletions or insertions), enclosing the latter in start and end.
+func highlight(diffs []diffmatchpatch.Diff, op diffmatchpatch.Operation, start, end string) string {
+	var buf bytes.Buffer
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			buf.WriteString(d.Text)
+		case op:
+			buf.WriteString(start)
+			buf.WriteString(d.Text)
+			buf.WriteString(end)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,164 @@
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/yudai/gojsondiff"
+)
+
+func ExampleJSONDiffer_Compare_textDiff() {
+	jd := JSONDiffer{
+		BasicEqualer:          TolerantBasicEqualer{},
+		TextDiffMinimumLength: 20,
+		TextDiffGranularity:   WordGranularity,
+	}
+	d, err := jd.Compare(
+		[]byte(`{"id": 1, "text": "The quick brown fox jumps over the lazy dog."}`),
+		[]byte(`{"id": 1, "text": "The quick red fox jumps over the lazy cat."}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	diff, err := d.Format(false) // no colouring
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(diff)
+	// Output:
+	//  {
+	//    "id": 1,
+	// -  "text": "The quick [-brown-] fox jumps over the lazy [-dog-]."
+	// +  "text": "The quick {+red+} fox jumps over the lazy {+cat+}."
+	//  }
+}
+
+func TestJSONDiffer_Compare_textDiff(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		textDiff bool
+		expected string
+	}
+	tcs := []testCase{
+		{`"short"`, `"shirt"`, false, "- \"short\"\n+ \"shirt\"\n"},
+		{`"a long string"`, `"a lung string"`, true, "- \"a l[-o-]ng string\"\n+ \"a l{+u+}ng string\"\n"},
+		{`"a long string"`, `"short"`, true, "- \"[-a long string-]\"\n+ \"{+short+}\"\n"},
+		{`"a long string"`, `1`, false, "- \"a long string\"\n+ 1\n"},
+		{`["a long string"]`, `["a long String"]`, true, " [\n-  0: \"a long [-s-]tring\"\n+  0: \"a long {+S+}tring\"\n ]\n"},
+		{`"a long string_1"`, `"a long string_2"`, false, ""},
+	}
+	jd := JSONDiffer{
+		BasicEqualer: TolerantBasicEqualer{StringTransformer: Chain{
+			UUIDMasker{},
+			RegexpReplacer{Regexp: regexp.MustCompile("_[^_]*$")},
+		}},
+		TextDiffMinimumLength: 10,
+	}
+	for _, tc := range tcs {
+		d, err := jd.Compare([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+			continue
+		}
+		if textDiff := containsTextDiff(d.Deltas()); textDiff != tc.textDiff {
+			t.Errorf("[%v == %v] expected TextDiff %v; got %v", tc.a, tc.b, tc.textDiff, textDiff)
+		}
+		if actual, err := d.Format(false); err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if d.Modified() && actual != tc.expected {
+			t.Errorf("[%v == %v] expected %q; got %q", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestJSONDiff_Format_textDiffColoring(t *testing.T) {
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}, TextDiffMinimumLength: 1}
+	d, err := jd.Compare([]byte(`{"a": "foo bar"}`), []byte(`{"a": "foo baz"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := d.Format(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"\"foo ba\x1b[7mr\x1b[27m\"", "\"foo ba\x1b[7mz\x1b[27m\""} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %q to contain %q", actual, expected)
+		}
+	}
+
+	// formatting mustn't affect the original deltas
+	if !containsTextDiff(d.Deltas()) {
+		t.Errorf("expected TextDiff; got %v", d.Deltas())
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	type testCase struct {
+		s        string
+		expected []string
+	}
+	tcs := []testCase{
+		{"", nil},
+		{"foo", []string{"foo"}},
+		{"foo bar", []string{"foo", " ", "bar"}},
+		{" foo,  bär2! ", []string{" ", "foo", ",", " ", " ", "bär2", "!", " "}},
+	}
+	for _, tc := range tcs {
+		if actual := splitWords(tc.s); fmt.Sprintf("%q", actual) != fmt.Sprintf("%q", tc.expected) {
+			t.Errorf("[%q] expected %q; got %q", tc.s, tc.expected, actual)
+		}
+	}
+}
+
+func TestTextDiffs_manyWords(t *testing.T) {
+	// distinct words beyond the surrogate halves must still be encoded as valid runes
+	var a, b []string
+	for i := 0; i < 60000; i++ {
+		a = append(a, fmt.Sprint("a", i))
+		b = append(b, fmt.Sprint("a", i))
+		if i%1000 == 0 {
+			b[i] = fmt.Sprint("b", i)
+		}
+	}
+	left, right := strings.Join(a, " "), strings.Join(b, " ")
+	diffs := textDiffs(left, right, WordGranularity)
+	var changed int
+	for _, d := range diffs {
+		if d.Type != diffmatchpatch.DiffEqual {
+			changed++
+		}
+	}
+	if changed != 120 {
+		t.Errorf("expected 120 changed words; got %v", changed)
+	}
+	if actual := highlight(diffs, diffmatchpatch.DiffDelete, "", ""); actual != left {
+		t.Errorf("expected left text to be restored; got %.100q...", actual)
+	}
+	if actual := highlight(diffs, diffmatchpatch.DiffInsert, "", ""); actual != right {
+		t.Errorf("expected right text to be restored; got %.100q...", actual)
+	}
+}
+
+func containsTextDiff(ds []gojsondiff.Delta) bool {
+	for _, d := range ds {
+		switch d := d.(type) {
+		case *gojsondiff.TextDiff:
+			return true
+		case *gojsondiff.Object:
+			if containsTextDiff(d.Deltas) {
+				return true
+			}
+		case *gojsondiff.Array:
+			if containsTextDiff(d.Deltas) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package compare
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTimeCacheSize specifies how many parsing results a TimeParser remembers.
+// When the cache is full, it's cleared, so memory usage remains bounded even if
+// a TimeParser is used for comparing a large number of distinct strings.
+const maxTimeCacheSize = 10000
+
+// Epoch specifies whether and how strings representing numbers should be
+// interpreted as Unix timestamps.
+type Epoch int
+
+const (
+	// EpochNone specifies that numbers shouldn't be interpreted as timestamps.
+	EpochNone Epoch = iota
+	// EpochSeconds specifies that numbers represent seconds since the Unix epoch.
+	EpochSeconds
+	// EpochMilliseconds specifies that numbers represent milliseconds since the Unix epoch.
+	EpochMilliseconds
+	// EpochAuto specifies that the unit of a number should be derived from its
+	// magnitude: numbers below 1e11 are interpreted as seconds, numbers below
+	// 1e14 as milliseconds, numbers below 1e17 as microseconds and all other
+	// numbers as nanoseconds since the Unix epoch.
+	EpochAuto
+)
+
+// TimeParser parses strings representing times.
+//
+// Since parsing results are cached, a TimeParser must be used by pointer, and
+// its fields must not be modified after it has been used for the first time.
+// It's safe to use a TimeParser from multiple goroutines.
+type TimeParser struct {
+	// Layouts specifies the layouts to try, in order (time.RFC3339 by default).
+	Layouts []string
+	// Epoch specifies whether strings representing numbers should be
+	// interpreted as Unix timestamps.
+	Epoch Epoch
+	// Location specifies where times without time zone information are
+	// located. If set, all parsed times are converted to this location.
+	Location *time.Location
+
+	mu    sync.RWMutex
+	cache map[string]parsedTime
+}
+
+type parsedTime struct {
+	t  time.Time
+	ok bool
+}
+
+// Parse parses a string representing a time. The second return value is false
+// iff the string couldn't be parsed according to any of the layouts and doesn't
+// represent a Unix timestamp (if enabled).
+func (p *TimeParser) Parse(s string) (time.Time, bool) {
+	p.mu.RLock()
+	pt, cached := p.cache[s]
+	p.mu.RUnlock()
+	if cached {
+		return pt.t, pt.ok
+	}
+
+	pt.t, pt.ok = p.parse(s)
+
+	p.mu.Lock()
+	if p.cache == nil || len(p.cache) >= maxTimeCacheSize {
+		p.cache = make(map[string]parsedTime)
+	}
+	p.cache[s] = pt
+	p.mu.Unlock()
+
+	return pt.t, pt.ok
+}
+
+func (p *TimeParser) parse(s string) (time.Time, bool) {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	t, ok := p.parseEpoch(s)
+	if !ok {
+		t, ok = p.parseLayouts(s, loc)
+	}
+	if ok && p.Location != nil {
+		t = t.In(p.Location)
+	}
+	return t, ok
+}
+
+func (p *TimeParser) parseLayouts(s string, loc *time.Location) (time.Time, bool) {
+	layouts := p.Layouts
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseEpoch interprets strings like "1522420569" or "1522420569.778" as Unix
+// timestamps. The fractional part is truncated to nanoseconds.
+func (p *TimeParser) parseEpoch(s string) (time.Time, bool) {
+	if p.Epoch == EpochNone {
+		return time.Time{}, false
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+		if fracPart == "" {
+			return time.Time{}, false
+		}
+	}
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || !isDigits(fracPart) {
+		return time.Time{}, false
+	}
+
+	unit := p.epochUnit(n)
+	nanos := time.Duration(0)
+	if fracPart != "" {
+		// scale fraction to the unit, e.g. ".5" seconds -> 500000000 nanoseconds
+		f, err := strconv.ParseFloat("0."+fracPart, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		nanos = time.Duration(f * float64(unit))
+		if strings.HasPrefix(intPart, "-") {
+			nanos = -nanos
+		}
+	}
+
+	sec, rem := n/int64(time.Second/unit), n%int64(time.Second/unit)
+	return time.Unix(sec, int64(time.Duration(rem)*unit+nanos)).UTC(), true
+}
+
+// epochUnit returns the unit of a Unix timestamp.
+func (p *TimeParser) epochUnit(n int64) time.Duration {
+	switch p.Epoch {
+	case EpochSeconds:
+		return time.Second
+	case EpochMilliseconds:
+		return time.Millisecond
+	}
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n < 1e11:
+		return time.Second
+	case n < 1e14:
+		return time.Millisecond
+	case n < 1e17:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
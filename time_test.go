@@ -0,0 +1,140 @@
+package compare
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func ExampleTimeParser() {
+	e := TolerantBasicEqualer{
+		TimeParser: &TimeParser{
+			Layouts: []string{time.RFC3339Nano, "2006-01-02 15:04:05", http.TimeFormat},
+			Epoch:   EpochAuto,
+		},
+		TimeTolerance: time.Second,
+	}
+	fmt.Println(e.String("2018-03-30T14:36:09Z", "1522420569"))
+	fmt.Println(e.String("2018-03-30T14:36:09Z", "1522420569778"))
+	fmt.Println(e.String("2018-03-30 14:36:09", "Fri, 30 Mar 2018 14:36:10 GMT"))
+	// Output:
+	// true
+	// true
+	// true
+}
+
+func TestTimeParser_Parse(t *testing.T) {
+	type testCase struct {
+		s        string
+		expected time.Time
+		ok       bool
+	}
+
+	utc := time.Date(2018, 3, 30, 14, 36, 9, 0, time.UTC)
+	cest := time.FixedZone("CEST", 2*60*60)
+
+	layouts := []testCase{
+		{"2018-03-30T14:36:09Z", utc, true},
+		{"2018-03-30T14:36:09.778Z", utc.Add(778 * time.Millisecond), true},
+		{"2018-03-30T16:36:09+02:00", utc, true},
+		{"2018-03-30 14:36:09", utc, true},
+		{"Fri, 30 Mar 2018 14:36:09 GMT", utc, true},
+		{"1522420569", time.Time{}, false},
+		{"foo", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+
+	epochs := []testCase{
+		{"1522420569", utc, true},
+		{"1522420569.778", utc.Add(778 * time.Millisecond), true},
+		{"1522420569778", utc.Add(778 * time.Millisecond), true},
+		{"1522420569778000", utc.Add(778 * time.Millisecond), true},
+		{"1522420569778000000", utc.Add(778 * time.Millisecond), true},
+		{"0", time.Unix(0, 0), true},
+		{"-1.5", time.Unix(-2, 500000000), true},
+		{"1522420569.", time.Time{}, false},
+		{".5", time.Time{}, false},
+		{"1522420569.7e3", time.Time{}, false},
+		{"2018-03-30T14:36:09Z", utc, true},
+	}
+
+	located := []testCase{
+		{"2018-03-30 16:36:09", utc, true},
+		{"2018-03-30T14:36:09Z", utc, true},
+		{"1522420569", utc, true},
+	}
+
+	p := &TimeParser{Layouts: []string{time.RFC3339Nano, "2006-01-02 15:04:05", http.TimeFormat}}
+	for _, tc := range layouts {
+		for i := 0; i < 2; i++ { // parse twice to make sure that cached results are correct
+			if actual, ok := p.Parse(tc.s); ok != tc.ok || !actual.Equal(tc.expected) {
+				t.Errorf("[%v] expected %v, %v; got %v, %v", tc.s, tc.expected, tc.ok, actual, ok)
+			}
+		}
+	}
+
+	p = &TimeParser{Epoch: EpochAuto}
+	for _, tc := range epochs {
+		if actual, ok := p.Parse(tc.s); ok != tc.ok || !actual.Equal(tc.expected) {
+			t.Errorf("[%v] expected %v, %v; got %v, %v", tc.s, tc.expected, tc.ok, actual, ok)
+		}
+	}
+
+	p = &TimeParser{Epoch: EpochMilliseconds}
+	if actual, ok := p.Parse("1522420569"); !ok || !actual.Equal(time.Unix(1522420, 569000000)) {
+		t.Errorf("[1522420569] expected milliseconds; got %v, %v", actual, ok)
+	}
+
+	p = &TimeParser{
+		Layouts:  []string{time.RFC3339, "2006-01-02 15:04:05"},
+		Epoch:    EpochSeconds,
+		Location: cest,
+	}
+	for _, tc := range located {
+		actual, ok := p.Parse(tc.s)
+		if ok != tc.ok || !actual.Equal(tc.expected) {
+			t.Errorf("[%v] expected %v, %v; got %v, %v", tc.s, tc.expected, tc.ok, actual, ok)
+		} else if actual.Location() != cest {
+			t.Errorf("[%v] expected location %v; got %v", tc.s, cest, actual.Location())
+		}
+	}
+}
+
+func TestTimeParser_Parse_cache(t *testing.T) {
+	p := &TimeParser{Epoch: EpochSeconds}
+	for i := 0; i < maxTimeCacheSize+10; i++ {
+		if _, ok := p.Parse(fmt.Sprint(i)); !ok {
+			t.Fatalf("[%v] expected a valid timestamp", i)
+		}
+	}
+	if len(p.cache) > maxTimeCacheSize {
+		t.Errorf("expected at most %v cached results; got %v", maxTimeCacheSize, len(p.cache))
+	}
+}
+
+func TestTolerantBasicEqualer_String_timeParser(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		expected bool
+	}
+	tcs := []testCase{
+		{"2018-03-30T14:36:09Z", "1522420569", true},
+		{"2018-03-30T14:36:09Z", "1522420570", true},
+		{"2018-03-30T14:36:09Z", "1522420571", false},
+		{"2018-03-30T16:36:09+02:00", "2018-03-30 14:36:09", true},
+		{"1522420569", "1522420569000", true},
+		{"1522420569", "foo", false},
+		{"foo", "foo", true},
+	}
+	e := TolerantBasicEqualer{
+		TimeParser:    &TimeParser{Layouts: []string{time.RFC3339, "2006-01-02 15:04:05"}, Epoch: EpochAuto},
+		TimeTolerance: time.Second,
+	}
+	for _, tc := range tcs {
+		if actual := e.String(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
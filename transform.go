@@ -0,0 +1,166 @@
+package compare
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	hexAddressRE = regexp.MustCompile(`\b0[xX][0-9a-fA-F]+\b`)
+	lineEndingRE = regexp.MustCompile(`\r\n?`)
+	spaceRE      = regexp.MustCompile(`\s+`)
+	uuidRE       = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+)
+
+// StringTransformerFunc is an adapter that allows using an ordinary function
+// as a StringTransformer.
+type StringTransformerFunc func(string) string
+
+// Transform calls f(s).
+func (f StringTransformerFunc) Transform(s string) string {
+	return f(s)
+}
+
+// Chain is a StringTransformer that applies several transformers in sequence.
+type Chain []StringTransformer
+
+// Transform applies all transformers in the chain, in order.
+func (c Chain) Transform(s string) string {
+	for _, t := range c {
+		s = t.Transform(s)
+	}
+	return s
+}
+
+// RegexpReplacer provides functionality for replacing substrings matching a
+// regular expression.
+type RegexpReplacer struct {
+	// Regexp specifies which substrings should be replaced.
+	Regexp *regexp.Regexp
+	// Replacement specifies what matching substrings should be replaced with.
+	// Inside Replacement, $ signs are interpreted as in regexp.Expand, so for
+	// instance $1 represents the text of the first capture group.
+	Replacement string
+}
+
+// Transform replaces all substrings matching a regular expression.
+func (rr RegexpReplacer) Transform(s string) string {
+	return rr.Regexp.ReplaceAllString(s, rr.Replacement)
+}
+
+// CaseFolder provides functionality for making strings case-insensitive.
+type CaseFolder struct{}
+
+// Transform maps every character to a canonical representative of the set of
+// characters that are equivalent under simple Unicode case folding. Unlike
+// strings.ToLower, it also handles characters like 'ſ' (which folds to 's')
+// and 'K' (the Kelvin sign, which folds to 'k').
+func (CaseFolder) Transform(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// foldRune returns the smallest rune that is equivalent to r under simple
+// Unicode case folding.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// Normalizer provides a function for normalizing Unicode strings.
+//
+// The normalization forms defined in golang.org/x/text/unicode/norm
+// (e.g. norm.NFD) implement this interface.
+type Normalizer interface {
+	// String returns the normalized form of a string.
+	String(string) string
+}
+
+// Unicode normalization forms (cf. https://unicode.org/reports/tr15/).
+var (
+	// NFC composes characters, e.g. 'e' followed by a combining acute accent
+	// to 'é'.
+	NFC Normalizer = norm.NFC
+	// NFKC additionally replaces compatibility characters with their
+	// canonical equivalents, e.g. 'ﬁ' with "fi" and '²' with '2'.
+	NFKC Normalizer = norm.NFKC
+)
+
+// UnicodeNormalizer provides functionality for normalizing Unicode strings,
+// so that e.g. "é" represented as a single code point and "é" represented as
+// 'e' followed by a combining acute accent are considered equal.
+type UnicodeNormalizer struct {
+	// Form specifies the normalization form, e.g. NFKC (NFC by default).
+	Form Normalizer
+}
+
+// Transform normalizes a string.
+func (un UnicodeNormalizer) Transform(s string) string {
+	if un.Form == nil {
+		return NFC.String(s)
+	}
+	return un.Form.String(s)
+}
+
+// SpaceCollapser provides functionality for collapsing whitespace.
+type SpaceCollapser struct{}
+
+// Transform replaces each sequence of whitespace characters with a single space.
+func (SpaceCollapser) Transform(s string) string {
+	return spaceRE.ReplaceAllString(s, " ")
+}
+
+// SpaceTrimmer provides functionality for trimming whitespace.
+type SpaceTrimmer struct{}
+
+// Transform removes leading and trailing whitespace.
+func (SpaceTrimmer) Transform(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// UUIDMasker provides functionality for masking UUIDs like
+// "123e4567-e89b-12d3-a456-426655440000", which are typically random.
+type UUIDMasker struct {
+	// Mask specifies what UUIDs should be replaced with ("<uuid>" by default).
+	Mask string
+}
+
+// Transform replaces all UUIDs with the mask.
+func (um UUIDMasker) Transform(s string) string {
+	return uuidRE.ReplaceAllLiteralString(s, maskOrDefault(um.Mask, "<uuid>"))
+}
+
+// HexAddressMasker provides functionality for masking hexadecimal addresses
+// like "0xc42000e1e0", which typically differ between runs.
+type HexAddressMasker struct {
+	// Mask specifies what addresses should be replaced with ("<addr>" by default).
+	Mask string
+}
+
+// Transform replaces all hexadecimal addresses with the mask.
+func (hm HexAddressMasker) Transform(s string) string {
+	return hexAddressRE.ReplaceAllLiteralString(s, maskOrDefault(hm.Mask, "<addr>"))
+}
+
+// LineEndingNormalizer provides functionality for normalizing line endings.
+type LineEndingNormalizer struct{}
+
+// Transform replaces Windows ("\r\n") and classic Mac OS ("\r") line endings
+// with Unix line endings ("\n").
+func (LineEndingNormalizer) Transform(s string) string {
+	return lineEndingRE.ReplaceAllLiteralString(s, "\n")
+}
+
+func maskOrDefault(mask, def string) string {
+	if mask == "" {
+		return def
+	}
+	return mask
+}
@@ -0,0 +1,101 @@
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func ExampleChain() {
+	e := TolerantBasicEqualer{
+		StringTransformer: Chain{
+			LineEndingNormalizer{},
+			UnicodeNormalizer{Form: NFKC},
+			SpaceCollapser{},
+			SpaceTrimmer{},
+			CaseFolder{},
+			UUIDMasker{},
+		},
+	}
+	fmt.Println(e.String(
+		"Created  order 123E4567-E89B-12D3-A456-426655440000 \ufb01nished\r\n",
+		"created order 0e9b4c5a-7d34-4c2f-9a61-8f2e5d6b1c3a finished"))
+	// Output: true
+}
+
+func TestStringTransformers(t *testing.T) {
+	type testCase struct {
+		t        StringTransformer
+		s        string
+		expected string
+	}
+	tcs := []testCase{
+		{StringTransformerFunc(strings.ToUpper), "foo", "FOO"},
+		{Chain{}, "foo", "foo"},
+		{Chain{SpaceTrimmer{}, StringTransformerFunc(strings.ToUpper)}, " foo ", "FOO"},
+		{RegexpReplacer{regexp.MustCompile(`id=(\d+)`), "id"}, "id=1, id=2", "id, id"},
+		{RegexpReplacer{regexp.MustCompile(`(\w+)@(\w+)`), "$2@$1"}, "foo@bar", "bar@foo"},
+		{RegexpReplacer{regexp.MustCompile(`(\w+)@(\w+)`), "${1}_x"}, "foo@bar", "foo_x"},
+		{CaseFolder{}, "Hello WORLD", "HELLO WORLD"},
+		{CaseFolder{}, "ſ", "S"},
+		{CaseFolder{}, "K", "K"}, // Kelvin sign
+		{CaseFolder{}, "ΣΑΣ σας", "ΣΑΣ ΣΑΣ"},
+		{CaseFolder{}, "123", "123"},
+		{UnicodeNormalizer{}, "e\u0301", "\u00e9"},
+		{UnicodeNormalizer{}, "\ufb01\u00b2", "\ufb01\u00b2"},
+		{UnicodeNormalizer{Form: NFKC}, "\ufb01\u00b2", "fi2"},
+		{UnicodeNormalizer{Form: NFKC}, "e\u0301", "\u00e9"},
+		{UnicodeNormalizer{stripCombiningMarks{}}, "e\u0301", "e"},
+		{SpaceCollapser{}, " foo \t\n bar  ", " foo bar "},
+		{SpaceTrimmer{}, " \tfoo bar\n", "foo bar"},
+		{UUIDMasker{}, "id: 123e4567-e89b-12d3-a456-426655440000", "id: <uuid>"},
+		{UUIDMasker{Mask: "*"}, "123E4567-E89B-12D3-A456-426655440000/x", "*/x"},
+		{UUIDMasker{}, "123e4567-e89b-12d3-a456-42665544000", "123e4567-e89b-12d3-a456-42665544000"},
+		{HexAddressMasker{}, "&{0xc42000e1e0 0X1F}", "&{<addr> <addr>}"},
+		{HexAddressMasker{Mask: "0x?"}, "0xc42000e1e0", "0x?"},
+		{HexAddressMasker{}, "0xg1, a0x1", "0xg1, a0x1"},
+		{LineEndingNormalizer{}, "a\r\nb\rc\nd\n\r", "a\nb\nc\nd\n\n"},
+	}
+	for _, tc := range tcs {
+		if actual := tc.t.Transform(tc.s); actual != tc.expected {
+			t.Errorf("[%T(%q)] expected %q; got %q", tc.t, tc.s, tc.expected, actual)
+		}
+	}
+}
+
+func TestJSONDiffer_Equal_transformed(t *testing.T) {
+	type testCase struct {
+		a        string
+		b        string
+		expected bool
+	}
+	tcs := []testCase{
+		{`"Foo "`, `"foo"`, true},
+		{`{"a": ["FOO", {"b": " bar"}]}`, `{"a": ["foo", {"b": "BAR "}]}`, true},
+		{`{"A": "foo"}`, `{"a": "foo"}`, false},
+		{`"foo"`, `"bar"`, false},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{StringTransformer: Chain{SpaceTrimmer{}, CaseFolder{}}}}
+	for _, tc := range tcs {
+		actual, err := jd.Equal([]byte(tc.a), []byte(tc.b))
+		if err != nil {
+			t.Errorf("[%v == %v] %v", tc.a, tc.b, err)
+		} else if actual != tc.expected {
+			t.Errorf("[%v == %v] expected %v; got %v", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+// stripCombiningMarks is a simplistic custom Normalizer that removes
+// combining diacritical marks.
+type stripCombiningMarks struct{}
+
+func (stripCombiningMarks) String(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 0x0300 && r <= 0x036f {
+			return -1
+		}
+		return r
+	}, s)
+}
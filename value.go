@@ -0,0 +1,426 @@
+package compare
+
+import (
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yudai/gojsondiff"
+)
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ValueDiff represents the differences between a Go value and a JSON document
+// (cf. JSONDiffer.CompareValue). The Go value is the left side.
+type ValueDiff struct {
+	*JSONDiff
+	// fieldPaths maps the JSON Pointers of the values encoded from the Go
+	// value to their paths within the Go value.
+	fieldPaths map[string]Path
+}
+
+// A ValueDifference is a difference between a Go value and a JSON document.
+type ValueDifference struct {
+	Difference
+	// FieldPath identifies the value within the Go value, using the names of
+	// struct fields rather than the names of JSON object elements, e.g.
+	// "Servers[0].MaxRetries" rather than "servers[0].max_retries".
+	FieldPath Path
+}
+
+// Differences returns the individual differences between the Go value and the
+// JSON document. Their paths identify the values within the JSON values.
+func (d *ValueDiff) Differences() []ValueDifference {
+	var diffs []ValueDifference
+	for _, diff := range d.JSONDiff.Differences() {
+		diffs = append(diffs, ValueDifference{Difference: diff, FieldPath: d.FieldPath(diff.Path)})
+	}
+	return diffs
+}
+
+// FieldPath returns the path within the Go value that corresponds to a path
+// within the JSON values. Elements that the Go value doesn't have, and values
+// within values encoded by json.Marshaler implementations, are identified by
+// their JSON names.
+func (d *ValueDiff) FieldPath(p Path) Path {
+	for i := len(p); i >= 0; i-- {
+		if fp, ok := d.fieldPaths[p[:i].Pointer()]; ok {
+			return append(fp.clone(), p[i:]...)
+		}
+	}
+	return p.clone() // should never happen, since the root is always mapped
+}
+
+// CompareValue returns the differences between a Go value and a JSON document.
+// It's equivalent to CompareValueContext(context.Background(), v, doc).
+func (jd JSONDiffer) CompareValue(v interface{}, doc []byte) (*ValueDiff, error) {
+	return jd.CompareValueContext(context.Background(), v, doc)
+}
+
+// CompareValueContext returns the differences between a Go value and a JSON
+// document. The Go value is compared as if it had been encoded with json.Marshal, i.e.
+// the `json` tags of struct fields (names, "-", omitempty and string) are
+// honored, the fields of embedded structs are promoted, and values whose
+// types implement json.Marshaler or encoding.TextMarshaler are encoded with
+// those methods. Returns an error if the document doesn't adhere to the JSON
+// syntax, if the value can't be encoded (like json.Marshal), or a *LimitError
+// if one of the Limits is exceeded or ctx is done before the comparison is
+// finished.
+func (jd JSONDiffer) CompareValueContext(ctx context.Context, v interface{}, doc []byte) (*ValueDiff, error) {
+	var r interface{}
+	if err := json.Unmarshal(doc, &r); err != nil {
+		return nil, err
+	}
+	enc := &valueEncoder{fieldPaths: map[string]Path{}, visiting: map[visit]bool{}}
+	l, err := enc.encode(reflect.ValueOf(v), nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	d, err := jd.compareDecoded(ctx, l, r)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueDiff{JSONDiff: d, fieldPaths: enc.fieldPaths}, nil
+}
+
+// valueEncoder converts Go values to decoded JSON values, like json.Marshal
+// followed by json.Unmarshal would, but remembering where each value came from.
+type valueEncoder struct {
+	fieldPaths map[string]Path
+	// visiting contains the pointers, maps and slices that are currently
+	// being encoded, in order to detect cycles.
+	visiting map[visit]bool
+}
+
+// visit identifies a pointer, map or slice (cf. valueEncoder.visiting).
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// encode converts v, which is located at the given paths within the JSON
+// values and the Go value, respectively. If quoted is true, basic values are
+// encoded as strings (cf. the string option of `json` tags).
+func (e *valueEncoder) encode(v reflect.Value, jsonPath, fieldPath Path, quoted bool) (interface{}, error) {
+	e.fieldPaths[jsonPath.Pointer()] = fieldPath.clone()
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if m, ok := marshaler(v); ok {
+		return normalizeJSON(m)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		key := visit{ptr: v.Pointer(), typ: v.Type()}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if e.visiting[key] {
+			return nil, &json.UnsupportedValueError{Value: v, Str: fmt.Sprintf("encountered a cycle via %s", v.Type())}
+		}
+		e.visiting[key] = true
+		defer delete(e.visiting, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return e.encode(v.Elem(), jsonPath, fieldPath, quoted)
+	case reflect.Struct:
+		return e.encodeStruct(v, jsonPath, fieldPath)
+	case reflect.Map:
+		return e.encodeMap(v, jsonPath, fieldPath)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && !implementsMarshaler(reflect.PtrTo(v.Type().Elem())) {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return e.encodeArray(v, jsonPath, fieldPath)
+	case reflect.Array:
+		return e.encodeArray(v, jsonPath, fieldPath)
+	}
+
+	b, err := encodeBasic(v)
+	if err != nil || !quoted {
+		return b, err
+	}
+	q, err := json.Marshal(b)
+	return string(q), err
+}
+
+func (e *valueEncoder) encodeStruct(v reflect.Value, jsonPath, fieldPath Path) (interface{}, error) {
+	m := map[string]interface{}{}
+	for _, f := range jsonFields(v.Type()) {
+		fv, fp, ok := fieldByIndex(v, f.index, fieldPath)
+		if !ok || f.omitEmpty && isEmptyJSONValue(fv) {
+			continue
+		}
+		var err error
+		if m[f.name], err = e.encode(fv, jsonPath.child(gojsondiff.Name(f.name)), fp, f.quoted); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (e *valueEncoder) encodeMap(v reflect.Value, jsonPath, fieldPath Path) (interface{}, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+	m := make(map[string]interface{}, v.Len())
+	for _, k := range v.MapKeys() {
+		key, err := mapKeyString(k)
+		if err != nil {
+			return nil, err
+		}
+		if m[key], err = e.encode(v.MapIndex(k), jsonPath.child(gojsondiff.Name(key)), fieldPath.child(mapKeyName(k)), false); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (e *valueEncoder) encodeArray(v reflect.Value, jsonPath, fieldPath Path) (interface{}, error) {
+	a := make([]interface{}, v.Len())
+	for i := range a {
+		var err error
+		if a[i], err = e.encode(v.Index(i), jsonPath.child(gojsondiff.Index(i)), fieldPath.child(gojsondiff.Index(i)), false); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// encodeBasic converts a value of a basic type to a decoded JSON value.
+func encodeBasic(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		bits := v.Type().Bits()
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, bits)}
+		}
+		if bits == 32 {
+			// like json.Marshal, use the shortest representation of the float32
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+		}
+		return f, nil
+	case reflect.String:
+		return v.String(), nil
+	}
+	return nil, &json.UnsupportedTypeError{Type: v.Type()}
+}
+
+// marshaler returns the value to pass to json.Marshal if v (or a pointer to
+// it) implements json.Marshaler or encoding.TextMarshaler.
+func marshaler(v reflect.Value) (interface{}, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	if implementsMarshaler(v.Type()) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, true
+		}
+		return v.Interface(), true
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && implementsMarshaler(reflect.PtrTo(v.Type())) {
+		return v.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+func implementsMarshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType)
+}
+
+// mapKeyString returns the name of the JSON object element for a map key.
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if k.CanInterface() && k.Type().Implements(textMarshalerType) {
+		tm := k.Interface().(encoding.TextMarshaler)
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+// isEmptyJSONValue determines if a struct field is omitted because of the
+// omitempty option of its `json` tag.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+// jsonField is a struct field (possibly promoted from an embedded struct)
+// that's encoded as a JSON object element.
+type jsonField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+// jsonFieldsCache maps struct types to their jsonFields.
+var jsonFieldsCache sync.Map
+
+// jsonFields returns the fields of a struct type that are encoded by
+// json.Marshal, in the order of their indexes. Like json.Marshal, it promotes
+// the fields of embedded structs and resolves conflicts between fields with
+// the same name: the least nested field wins, and among fields with the same
+// depth, the field that's tagged with the name. Otherwise, all of them are omitted.
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	var fields []jsonField
+	visited := map[reflect.Type]bool{}
+	for next := []embedded{{t: t}}; len(next) > 0; {
+		current := next
+		next = nil
+		for _, emb := range current {
+			if visited[emb.t] {
+				continue
+			}
+			visited[emb.t] = true
+			for i := 0; i < emb.t.NumField(); i++ {
+				sf := emb.t.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && sf.PkgPath != "" && ft.Kind() != reflect.Struct || !sf.Anonymous && sf.PkgPath != "" {
+					continue // unexported
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if i := strings.Index(tag, ","); i >= 0 {
+					name, opts = tag[:i], tag[i:]
+				}
+				index := append(emb.index[:len(emb.index):len(emb.index)], i)
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{t: ft, index: index})
+					continue
+				}
+				f := jsonField{name: name, index: index, tagged: name != "", omitEmpty: strings.Contains(opts+",", ",omitempty,")}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				switch ft.Kind() {
+				case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+					reflect.Float32, reflect.Float64, reflect.String:
+					f.quoted = strings.Contains(opts+",", ",string,")
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	// resolve conflicts between fields with the same name
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		// fields[i] is the least nested field, preferably a tagged one
+		if j == i+1 || len(fields[i+1].index) > len(fields[i].index) || fields[i].tagged && !fields[i+1].tagged {
+			dominant = append(dominant, fields[i])
+		}
+		i = j
+	}
+	sort.Slice(dominant, func(i, j int) bool {
+		a, b := dominant[i].index, dominant[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	jsonFieldsCache.Store(t, dominant)
+	return dominant
+}
+
+// fieldByIndex returns a (possibly promoted) field of a struct and its path
+// within the Go value. The last return value is false if the field is
+// promoted from an embedded struct that's referenced by a nil pointer.
+func fieldByIndex(v reflect.Value, index []int, fieldPath Path) (reflect.Value, Path, bool) {
+	fp := fieldPath.clone()
+	for k, i := range index {
+		if k > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, nil, false
+				}
+				v = v.Elem()
+			}
+		}
+		fp = append(fp, gojsondiff.Name(v.Type().Field(i).Name))
+		v = v.Field(i)
+	}
+	return v, fp, true
+}
@@ -0,0 +1,214 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/yudai/gojsondiff"
+)
+
+func ExampleJSONDiffer_CompareValue() {
+	type server struct {
+		Host       string `json:"host"`
+		MaxRetries int    `json:"max_retries,omitempty"`
+	}
+	type config struct {
+		Servers []server `json:"servers"`
+		Debug   bool     `json:"-"`
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	d, err := jd.CompareValue(
+		config{Servers: []server{{"a", 3}, {"b", 0}}, Debug: true},
+		[]byte(`{"servers": [{"host": "a", "max_retries": 5}, {"host": "b", "max_retries": 1}]}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, diff := range d.Differences() {
+		fmt.Println(diff.Path, diff.FieldPath, diff.Operation, diff.Left, diff.Right)
+	}
+	// Output:
+	// servers[0].max_retries Servers[0].MaxRetries replace 3 5
+	// servers[1].max_retries Servers[1].max_retries add <nil> 1
+}
+
+type base struct {
+	ID      int    `json:"id"`
+	Created string `json:"created,omitempty"`
+}
+
+type audit struct {
+	By string
+	ID string // hidden by base.ID (with the same depth, but tagged)
+}
+
+type celsius float64
+
+func (c celsius) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%.1f°C", float64(c))), nil
+}
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]int{p.X, p.Y})
+}
+
+type level int
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("L%d", int(l))), nil
+}
+
+func TestJSONDiffer_CompareValue_marshal(t *testing.T) {
+	// values must be equal to their JSON encodings
+	type embedded struct {
+		base
+		*audit
+		Name     string            `json:"name"`
+		Skipped  string            `json:"-"`
+		Dash     string            `json:"-,"`
+		Count    int64             `json:",string"`
+		Ratio    float32           `json:"ratio,string"`
+		Flag     *bool             `json:"flag,string,omitempty"`
+		Quoted   string            `json:"quoted,string"`
+		Temp     celsius           `json:"temp"`
+		Temps    map[level]celsius `json:"temps"`
+		Location *point            `json:"location"`
+		Points   []point           `json:"points"`
+		Raw      json.RawMessage   `json:"raw"`
+		Bytes    []byte            `json:"bytes"`
+		Any      interface{}       `json:"any"`
+		Empty    []int             `json:"empty,omitempty"`
+		private  int
+	}
+	yes := true
+	shared := &point{1, 2}
+	values := []interface{}{
+		nil,
+		1,
+		uint8(255),
+		float32(0.1),
+		math.MaxInt32,
+		"<a & b>",
+		[]string{"a", "b"},
+		[2]bool{true, false},
+		map[int]string{1: "a", -2: "b"},
+		map[string]interface{}{"a": []interface{}{1, "b", nil}},
+		time.Date(2018, 3, 30, 16, 41, 11, 0, time.UTC),
+		net.ParseIP("10.0.0.1"),
+		&point{1, 2},
+		[]*point{nil, {3, 4}},
+		[]*point{shared, shared}, // not a cycle
+		[][]int{{1}, {1}},
+		embedded{},
+		embedded{
+			base:     base{ID: 1, Created: "today"},
+			audit:    &audit{By: "me", ID: "x"},
+			Name:     "n",
+			Skipped:  "s",
+			Dash:     "d",
+			Count:    -12,
+			Ratio:    0.3,
+			Flag:     &yes,
+			Quoted:   "q\"q",
+			Temp:     21.5,
+			Temps:    map[level]celsius{1: 20, 2: 30},
+			Location: &point{5, 6},
+			Points:   []point{{7, 8}},
+			Raw:      json.RawMessage(`{"x": [1, 2]}`),
+			Bytes:    []byte("bytes"),
+			Any:      map[string]int{"a": 1},
+			private:  1,
+		},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for _, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := jd.CompareValue(v, b)
+		if err != nil {
+			t.Errorf("[%#v] %v", v, err)
+		} else if d.Modified() {
+			t.Errorf("[%#v] expected no differences from %s; got %v", v, b, d.Differences())
+		}
+	}
+}
+
+func TestJSONDiffer_CompareValue_paths(t *testing.T) {
+	type item struct {
+		base
+		Name  string            `json:"name"`
+		Tags  map[string]string `json:"tags"`
+		Where *point            `json:"where"`
+	}
+	type order struct {
+		Items []item `json:"items"`
+		Total float64
+	}
+	v := order{
+		Items: []item{{base: base{ID: 1}, Name: "a", Tags: map[string]string{"x/y": "1"}, Where: &point{1, 2}}},
+		Total: 9.99,
+	}
+	doc := `{"items": [{"id": 2, "name": "a", "tags": {"x/y": "2"}, "where": [1, 3], "extra": 1}], "Total": 10}`
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{Float64Tolerance: 0.1}}
+	d, err := jd.CompareValue(v, []byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, diff := range d.Differences() {
+		actual = append(actual, fmt.Sprintf("%s %s %s", diff.Path.Pointer(), diff.FieldPath.Pointer(), diff.Operation))
+	}
+	expected := "[/items/0/id /Items/0/base/ID replace /items/0/tags/x~1y /Items/0/Tags/x~1y replace " +
+		"/items/0/where/1 /Items/0/Where/1 replace /items/0/extra /Items/0/extra add]"
+	if fmt.Sprint(actual) != expected {
+		t.Errorf("expected %v; got %v", expected, actual)
+	}
+	if expected := "[/Total 9.99 10 Float64Tolerance: 0.1]"; describeTolerances(d.Tolerances()) != expected {
+		t.Errorf("expected %v; got %v", expected, describeTolerances(d.Tolerances()))
+	}
+	if expected := "Items[0].base.ID"; d.FieldPath(Path{gojsondiff.Name("items"), gojsondiff.Index(0), gojsondiff.Name("id")}).String() != expected {
+		t.Errorf("expected %v; got %v", expected, d.FieldPath(Path{gojsondiff.Name("items"), gojsondiff.Index(0), gojsondiff.Name("id")}))
+	}
+}
+
+func TestJSONDiffer_CompareValue_errors(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	cycle := &node{}
+	cycle.Next = cycle
+	loop := map[string]interface{}{}
+	loop["self"] = []interface{}{loop}
+	type testCase struct {
+		v        interface{}
+		doc      string
+		expected string
+	}
+	tcs := []testCase{
+		{1, `{`, "unexpected end of JSON input"},
+		{make(chan int), `1`, "json: unsupported type: chan int"},
+		{map[point]int{{}: 1}, `{}`, "json: unsupported type: compare.point"},
+		{struct{ F func() }{}, `{}`, "json: unsupported type: func()"},
+		{[]interface{}{complex(1, 2)}, `[]`, "json: unsupported type: complex128"},
+		{cycle, `{}`, "json: unsupported value: encountered a cycle via *compare.node"},
+		{loop, `{}`, "json: unsupported value: encountered a cycle via map[string]interface {}"},
+		{math.NaN(), `0`, "json: unsupported value: NaN"},
+		{[]float32{float32(math.Inf(-1))}, `[]`, "json: unsupported value: -Inf"},
+	}
+	jd := JSONDiffer{BasicEqualer: TolerantBasicEqualer{}}
+	for i, tc := range tcs {
+		if _, err := jd.CompareValue(tc.v, []byte(tc.doc)); err == nil || err.Error() != tc.expected {
+			t.Errorf("[%d] expected %v; got %v", i, tc.expected, err)
+		}
+	}
+}